@@ -0,0 +1,172 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	autoextendConfigRefreshInterval = kingpin.Flag("collector.autoextend.config-refresh-interval",
+		"How long the autoextend settings read with lvmconfig are reused before running it again.",
+	).Default("10m").Duration()
+)
+
+// maxAutoextendSteps bounds the simulation of the successive extensions
+// of a thin pool.
+const maxAutoextendSteps = 10000
+
+// autoextendConfig caches the settings read with lvmconfig across scrapes,
+// since they seldom change.
+var autoextendConfig = struct {
+	sync.Mutex
+	config *lvm.AutoextendConfig
+	time   time.Time
+}{}
+
+// getAutoextendConfig returns the autoextend settings, running lvmconfig
+// only when they were not read within the refresh interval. A failure is
+// not cached, lvmconfig being run again on the next call.
+func getAutoextendConfig(ctx context.Context) (*lvm.AutoextendConfig, error) {
+	autoextendConfig.Lock()
+	defer autoextendConfig.Unlock()
+	if autoextendConfig.config != nil && time.Since(autoextendConfig.time) < *autoextendConfigRefreshInterval {
+		return autoextendConfig.config, nil
+	}
+	config, err := lvm.GetAutoextendConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	autoextendConfig.config = config
+	autoextendConfig.time = time.Now()
+	return config, nil
+}
+
+// autoextendCollector exposes the autoextend policy of lvm.conf and the
+// dmeventd monitoring of the logical volumes, which tell whether a thin
+// pool or snapshot filling up will be extended before it overflows.
+type autoextendCollector struct {
+	scrape *Scrape
+
+	lvMonitoredMetric          *prometheus.Desc
+	autoextendThresholdMetric  *prometheus.Desc
+	autoextendPercentMetric    *prometheus.Desc
+	monitoringMetric           *prometheus.Desc
+	tpAutoextendHeadroomMetric *prometheus.Desc
+}
+
+func init() {
+	registerCollector("autoextend", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewAutoextendCollector(scrape)
+	})
+}
+
+// NewAutoextendCollector initializes every descriptor and returns a pointer to the collector
+func NewAutoextendCollector(scrape *Scrape) *autoextendCollector {
+	return &autoextendCollector{
+		scrape: scrape,
+		lvMonitoredMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "monitored"),
+			"Whether the LVM LV is monitored by dmeventd, only reported for the LVs which can be",
+			[]string{"name", "vg"}, nil,
+		),
+		autoextendThresholdMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "config", "autoextend_threshold_percent"),
+			"Usage percent above which dmeventd extends the thin pools or snapshots, 100 if they are not extended",
+			[]string{"type"}, nil,
+		),
+		autoextendPercentMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "config", "autoextend_percent"),
+			"Percent of their size by which dmeventd extends the thin pools or snapshots",
+			[]string{"type"}, nil,
+		),
+		monitoringMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "config", "monitoring"),
+			"Whether the LVs are monitored by dmeventd when activated",
+			nil, nil,
+		),
+		tpAutoextendHeadroomMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "autoextend_headroom_bytes"),
+			"Data which can still be written to the monitored LVM thin pool before it reaches its autoextend threshold and the VG lacks the free space to extend it, in bytes",
+			[]string{"name", "vg"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *autoextendCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.lvMonitoredMetric
+	ch <- collector.autoextendThresholdMetric
+	ch <- collector.autoextendPercentMetric
+	ch <- collector.monitoringMetric
+	ch <- collector.tpAutoextendHeadroomMetric
+}
+
+// Update implements the Collector interface, it fails when the lvm report
+// or the lvm config cannot be fetched.
+func (collector *autoextendCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the lvm report: %w", err)
+	}
+	for _, lv := range report.LogicalVolumes {
+		if lv.Hidden || lv.Monitor == "" {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(collector.lvMonitoredMetric, prometheus.GaugeValue, boolToFloat64(lv.Monitor == "monitored"), lv.Name, lv.VGName)
+	}
+
+	config, err := collector.scrape.AutoextendConfig()
+	if err != nil {
+		return fmt.Errorf("error in getting the lvm config: %w", err)
+	}
+	ch <- prometheus.MustNewConstMetric(collector.autoextendThresholdMetric, prometheus.GaugeValue, float64(config.ThinPoolThreshold), "thin_pool")
+	ch <- prometheus.MustNewConstMetric(collector.autoextendThresholdMetric, prometheus.GaugeValue, float64(config.SnapshotThreshold), "snapshot")
+	ch <- prometheus.MustNewConstMetric(collector.autoextendPercentMetric, prometheus.GaugeValue, float64(config.ThinPoolPercent), "thin_pool")
+	ch <- prometheus.MustNewConstMetric(collector.autoextendPercentMetric, prometheus.GaugeValue, float64(config.SnapshotPercent), "snapshot")
+	ch <- prometheus.MustNewConstMetric(collector.monitoringMetric, prometheus.GaugeValue, boolToFloat64(config.Monitoring))
+
+	// Pools which are not extended have no headroom beyond their free
+	// space, already given by their data percent.
+	if config.ThinPoolThreshold >= 100 || config.ThinPoolPercent <= 0 {
+		return nil
+	}
+	vgs := make(map[string]lvm.VolumeGroup)
+	for _, vg := range report.VolumeGroups {
+		vgs[vg.Name] = vg
+	}
+	for _, lv := range report.LogicalVolumes {
+		if lv.SegType != lvm.LVThinPool || lv.Attr.State != "active" || lv.Monitor != "monitored" {
+			continue
+		}
+		vg, ok := vgs[lv.VGName]
+		if !ok {
+			continue
+		}
+		size := lv.Size.AsApproximateFloat64()
+		headroom := autoextendHeadroom(size, size*lv.UsedSizePercent/100,
+			vg.Free.AsApproximateFloat64(), vg.ExtentSize.AsApproximateFloat64(),
+			config.ThinPoolThreshold, config.ThinPoolPercent)
+		ch <- prometheus.MustNewConstMetric(collector.tpAutoextendHeadroomMetric, prometheus.GaugeValue, headroom, lv.Name, lv.VGName)
+	}
+	return nil
+}
+
+// autoextendHeadroom returns how much more data a volume can take before
+// reaching the threshold percent of its size once it can no longer be
+// extended by percent of its size from the free space of its volume group.
+func autoextendHeadroom(size, used, free, extentSize float64, threshold, percent int64) float64 {
+	for i := 0; i < maxAutoextendSteps; i++ {
+		step := size * float64(percent) / 100
+		// lvextend rounds the size up to whole extents.
+		if extentSize > 0 {
+			step = math.Ceil(step/extentSize) * extentSize
+		}
+		if step <= 0 || step > free {
+			break
+		}
+		size += step
+		free -= step
+	}
+	return math.Max(size*float64(threshold)/100-used, 0)
+}
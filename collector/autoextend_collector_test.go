@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestAutoextendHeadroom(t *testing.T) {
+	const (
+		gib        = 1 << 30
+		extentSize = 4 << 20
+	)
+	tests := []struct {
+		name               string
+		size, used, free   float64
+		threshold, percent int64
+		want               float64
+	}{
+		{
+			// The pool of the fixtures, extended twice by 20% of its size
+			// rounded up to whole extents before the vg runs out of space.
+			name: "extended", size: 4 * gib, used: 2 * gib, free: 2931818496,
+			threshold: 70, percent: 20, want: 2183135232,
+		},
+		{
+			name: "no free space", size: 4 * gib, used: 2 * gib, free: 0,
+			threshold: 75, percent: 20, want: gib,
+		},
+		{
+			name: "not extended", size: 4 * gib, used: gib, free: 100 * gib,
+			threshold: 80, percent: 0, want: 0.8*4*gib - gib,
+		},
+		{
+			name: "above threshold", size: 4 * gib, used: 3.5 * gib, free: 0,
+			threshold: 70, percent: 20, want: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := autoextendHeadroom(test.size, test.used, test.free, extentSize, test.threshold, test.percent)
+			if got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
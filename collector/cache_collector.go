@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog"
+)
+
+// cacheCollector exposes the statistics of the logical volumes cached
+// with dm-cache, as reported by lvs, and with dm-writecache, as reported
+// by the device mapper.
+type cacheCollector struct {
+	scrape *Scrape
+
+	cacheInfoMetric                 *prometheus.Desc
+	cacheTotalBlocksMetric          *prometheus.Desc
+	cacheUsedBlocksMetric           *prometheus.Desc
+	cacheDirtyBlocksMetric          *prometheus.Desc
+	cacheReadHitsMetric             *prometheus.Desc
+	cacheReadMissesMetric           *prometheus.Desc
+	cacheWriteHitsMetric            *prometheus.Desc
+	cacheWriteMissesMetric          *prometheus.Desc
+	cacheReadHitRatioMetric         *prometheus.Desc
+	cacheWriteHitRatioMetric        *prometheus.Desc
+	writecacheErrorMetric           *prometheus.Desc
+	writecacheTotalBlocksMetric     *prometheus.Desc
+	writecacheFreeBlocksMetric      *prometheus.Desc
+	writecacheWritebackBlocksMetric *prometheus.Desc
+}
+
+func init() {
+	registerCollector("cache", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewCacheCollector(scrape)
+	})
+}
+
+// NewCacheCollector initializes every descriptor and returns a pointer to the collector
+func NewCacheCollector(scrape *Scrape) *cacheCollector {
+	return &cacheCollector{
+		scrape: scrape,
+		cacheInfoMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "info"),
+			"Cache mode and policy of the cached LV",
+			[]string{"name", "vg", "mode", "policy"}, nil,
+		),
+		cacheTotalBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "total_blocks"),
+			"Total number of cache blocks of the cached LV",
+			[]string{"name", "vg"}, nil,
+		),
+		cacheUsedBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "used_blocks"),
+			"Number of used cache blocks of the cached LV",
+			[]string{"name", "vg"}, nil,
+		),
+		cacheDirtyBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "dirty_blocks"),
+			"Number of dirty cache blocks of the cached LV",
+			[]string{"name", "vg"}, nil,
+		),
+		cacheReadHitsMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "read_hits_total"),
+			"Number of cache read hits of the cached LV since its activation",
+			[]string{"name", "vg"}, nil,
+		),
+		cacheReadMissesMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "read_misses_total"),
+			"Number of cache read misses of the cached LV since its activation",
+			[]string{"name", "vg"}, nil,
+		),
+		cacheWriteHitsMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "write_hits_total"),
+			"Number of cache write hits of the cached LV since its activation",
+			[]string{"name", "vg"}, nil,
+		),
+		cacheWriteMissesMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "write_misses_total"),
+			"Number of cache write misses of the cached LV since its activation",
+			[]string{"name", "vg"}, nil,
+		),
+		cacheReadHitRatioMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "read_hit_ratio"),
+			"Ratio of the cache read hits to all the reads of the cached LV since its activation",
+			[]string{"name", "vg"}, nil,
+		),
+		cacheWriteHitRatioMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "cache", "write_hit_ratio"),
+			"Ratio of the cache write hits to all the writes of the cached LV since its activation",
+			[]string{"name", "vg"}, nil,
+		),
+		writecacheErrorMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "writecache", "error"),
+			"Whether an I/O error occurred on the writecache: [0: no], [1: yes]",
+			[]string{"name", "vg"}, nil,
+		),
+		writecacheTotalBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "writecache", "total_blocks"),
+			"Total number of blocks of the writecache",
+			[]string{"name", "vg"}, nil,
+		),
+		writecacheFreeBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "writecache", "free_blocks"),
+			"Number of free blocks of the writecache",
+			[]string{"name", "vg"}, nil,
+		),
+		writecacheWritebackBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "writecache", "writeback_blocks"),
+			"Number of blocks of the writecache under writeback",
+			[]string{"name", "vg"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.cacheInfoMetric
+	ch <- collector.cacheTotalBlocksMetric
+	ch <- collector.cacheUsedBlocksMetric
+	ch <- collector.cacheDirtyBlocksMetric
+	ch <- collector.cacheReadHitsMetric
+	ch <- collector.cacheReadMissesMetric
+	ch <- collector.cacheWriteHitsMetric
+	ch <- collector.cacheWriteMissesMetric
+	ch <- collector.cacheReadHitRatioMetric
+	ch <- collector.cacheWriteHitRatioMetric
+	ch <- collector.writecacheErrorMetric
+	ch <- collector.writecacheTotalBlocksMetric
+	ch <- collector.writecacheFreeBlocksMetric
+	ch <- collector.writecacheWritebackBlocksMetric
+}
+
+// Update implements the Collector interface, it fails when the list of
+// lvm logical volumes or, with writecache LVs, the device mapper status
+// cannot be fetched.
+func (collector *cacheCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the list of lvm logical volumes: %w", err)
+	}
+
+	var writecaches []lvm.LogicalVolume
+	for _, lv := range report.LogicalVolumes {
+		switch lv.SegType {
+		case lvm.LVCache:
+			collector.updateCache(ch, lv)
+		case lvm.LVWritecache:
+			writecaches = append(writecaches, lv)
+		}
+	}
+	if len(writecaches) == 0 {
+		return nil
+	}
+
+	dmStatus, err := collector.scrape.DMStatus()
+	if err != nil {
+		return fmt.Errorf("error in getting the device mapper status: %w", err)
+	}
+	for _, lv := range writecaches {
+		// Inactive LVs have no device mapper target.
+		status, ok := dmStatus[lvm.DMName(lv.VGName, lv.Name)]
+		if !ok {
+			continue
+		}
+		wc, err := lvm.ParseWritecacheStatus(status)
+		if err != nil {
+			klog.Errorf("error in parsing the status of writecache %v/%v: %v", lv.VGName, lv.Name, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(collector.writecacheErrorMetric, prometheus.GaugeValue, boolToFloat64(wc.Error), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.writecacheTotalBlocksMetric, prometheus.GaugeValue, float64(wc.TotalBlocks), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.writecacheFreeBlocksMetric, prometheus.GaugeValue, float64(wc.FreeBlocks), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.writecacheWritebackBlocksMetric, prometheus.GaugeValue, float64(wc.WritebackBlocks), lv.Name, lv.VGName)
+	}
+	return nil
+}
+
+func (collector *cacheCollector) updateCache(ch chan<- prometheus.Metric, lv lvm.LogicalVolume) {
+	ch <- prometheus.MustNewConstMetric(collector.cacheInfoMetric, prometheus.GaugeValue, 1, lv.Name, lv.VGName, lv.CacheMode, lv.CachePolicy)
+	ch <- prometheus.MustNewConstMetric(collector.cacheTotalBlocksMetric, prometheus.GaugeValue, float64(lv.CacheTotalBlocks), lv.Name, lv.VGName)
+	ch <- prometheus.MustNewConstMetric(collector.cacheUsedBlocksMetric, prometheus.GaugeValue, float64(lv.CacheUsedBlocks), lv.Name, lv.VGName)
+	ch <- prometheus.MustNewConstMetric(collector.cacheDirtyBlocksMetric, prometheus.GaugeValue, float64(lv.CacheDirtyBlocks), lv.Name, lv.VGName)
+	ch <- prometheus.MustNewConstMetric(collector.cacheReadHitsMetric, prometheus.CounterValue, float64(lv.CacheReadHits), lv.Name, lv.VGName)
+	ch <- prometheus.MustNewConstMetric(collector.cacheReadMissesMetric, prometheus.CounterValue, float64(lv.CacheReadMisses), lv.Name, lv.VGName)
+	ch <- prometheus.MustNewConstMetric(collector.cacheWriteHitsMetric, prometheus.CounterValue, float64(lv.CacheWriteHits), lv.Name, lv.VGName)
+	ch <- prometheus.MustNewConstMetric(collector.cacheWriteMissesMetric, prometheus.CounterValue, float64(lv.CacheWriteMisses), lv.Name, lv.VGName)
+	ch <- prometheus.MustNewConstMetric(collector.cacheReadHitRatioMetric, prometheus.GaugeValue, hitRatio(lv.CacheReadHits, lv.CacheReadMisses), lv.Name, lv.VGName)
+	ch <- prometheus.MustNewConstMetric(collector.cacheWriteHitRatioMetric, prometheus.GaugeValue, hitRatio(lv.CacheWriteHits, lv.CacheWriteMisses), lv.Name, lv.VGName)
+}
+
+// hitRatio returns the ratio of hits to all the accesses, 0 without access.
+func hitRatio(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
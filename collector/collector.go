@@ -0,0 +1,300 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"k8s.io/klog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultEnabled  = true
+	defaultDisabled = false
+)
+
+var (
+	factories        = make(map[string]func(scrape *Scrape) Collector)
+	collectorState   = make(map[string]*bool)
+	forcedCollectors = map[string]bool{} // collectors which have been explicitly enabled or disabled
+
+	enumStateSets = kingpin.Flag("collector.enum-state-sets",
+		"Expose the fields backed by lvm enums as one series per possible value with a state label, instead of the index of the value. A value the exporter does not know is reported as state \"other\" with a raw label.",
+	).Default("false").Bool()
+	infoMetrics = kingpin.Flag("collector.info-metrics",
+		"Move the descriptive labels of the lv and pv metrics to lvm_lv_info and lvm_pv_info, keying the other metrics by vg, name and uuid only.",
+	).Default("false").Bool()
+	tagLabels = kingpin.Flag("collector.tag-label",
+		"Key of the key=value lvm tags to add as a tag_<key> label to the lv and vg metrics, can be repeated.",
+	).Strings()
+
+	invalidLabelChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+)
+
+// registerCollector adds a collector along with its --collector.<name>
+// flag. It is meant to be called from the init function of the file
+// implementing the collector.
+func registerCollector(collector string, isDefaultEnabled bool, factory func(scrape *Scrape) Collector) {
+	var helpDefaultState string
+	if isDefaultEnabled {
+		helpDefaultState = "enabled"
+	} else {
+		helpDefaultState = "disabled"
+	}
+
+	flagName := fmt.Sprintf("collector.%s", collector)
+	flagHelp := fmt.Sprintf("Enable the %s collector (default: %s).", collector, helpDefaultState)
+	defaultValue := fmt.Sprintf("%v", isDefaultEnabled)
+
+	flag := kingpin.Flag(flagName, flagHelp).Default(defaultValue).Action(collectorFlagAction(collector)).Bool()
+	collectorState[collector] = flag
+
+	factories[collector] = factory
+}
+
+func collectorFlagAction(collector string) func(ctx *kingpin.ParseContext) error {
+	return func(ctx *kingpin.ParseContext) error {
+		forcedCollectors[collector] = true
+		return nil
+	}
+}
+
+// DisableDefaultCollectors sets the collector state to false for all
+// collectors which have not been explicitly enabled on the command line.
+func DisableDefaultCollectors() {
+	for c := range collectorState {
+		if _, ok := forcedCollectors[c]; !ok {
+			*collectorState[c] = false
+		}
+	}
+}
+
+// EnabledCollectors returns the sorted names of the enabled collectors.
+func EnabledCollectors() []string {
+	var names []string
+	for name, enabled := range collectorState {
+		if *enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	scrapeDurationDesc = prometheus.NewDesc(prometheus.BuildFQName("lvm", "scrape", "collector_duration_seconds"),
+		"lvm_exporter: Duration of a collector scrape.",
+		[]string{"collector"}, nil,
+	)
+	scrapeSuccessDesc = prometheus.NewDesc(prometheus.BuildFQName("lvm", "scrape", "collector_success"),
+		"lvm_exporter: Whether a collector succeeded.",
+		[]string{"collector"}, nil,
+	)
+	snapshotAgeDesc = prometheus.NewDesc(prometheus.BuildFQName("lvm", "snapshot", "age_seconds"),
+		"lvm_exporter: Age of the lvm snapshot the metrics were collected from.",
+		nil, nil,
+	)
+)
+
+// Collector is the interface a collector has to implement.
+type Collector interface {
+	// Describe sends the descriptors of every metric of the collector.
+	Describe(ch chan<- *prometheus.Desc)
+
+	// Update sends the metrics of the collector, or returns an error if
+	// they could not be collected.
+	Update(ch chan<- prometheus.Metric) error
+}
+
+// LvmCollector implements the prometheus.Collector interface by running
+// all its collectors concurrently and reporting whether each of them
+// succeeded and how long it took.
+type LvmCollector struct {
+	Collectors map[string]Collector
+
+	scrape *Scrape
+}
+
+// NewLvmCollector returns a LvmCollector running the enabled collectors
+// for the given scrape. When filters are given, only the collectors they
+// name are run, each of which must be enabled.
+func NewLvmCollector(scrape *Scrape, filters ...string) (*LvmCollector, error) {
+	f := make(map[string]bool)
+	for _, filter := range filters {
+		enabled, exist := collectorState[filter]
+		if !exist {
+			return nil, fmt.Errorf("missing collector: %s", filter)
+		}
+		if !*enabled {
+			return nil, fmt.Errorf("disabled collector: %s", filter)
+		}
+		f[filter] = true
+	}
+
+	collectors := make(map[string]Collector)
+	for key, enabled := range collectorState {
+		if !*enabled || (len(f) > 0 && !f[key]) {
+			continue
+		}
+		collectors[key] = factories[key](scrape)
+	}
+	return &LvmCollector{Collectors: collectors, scrape: scrape}, nil
+}
+
+// Describe implements the prometheus.Collector interface.
+func (l *LvmCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+	ch <- snapshotAgeDesc
+	for _, c := range l.Collectors {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (l *LvmCollector) Collect(ch chan<- prometheus.Metric) {
+	wg := sync.WaitGroup{}
+	wg.Add(len(l.Collectors))
+	for name, c := range l.Collectors {
+		go func(name string, c Collector) {
+			execute(name, c, ch)
+			wg.Done()
+		}(name, c)
+	}
+	wg.Wait()
+
+	// The snapshot is only taken if one of the collectors needed it.
+	if l.scrape.snapshot != nil {
+		age := time.Since(l.scrape.snapshot.Time).Seconds()
+		ch <- prometheus.MustNewConstMetric(snapshotAgeDesc, prometheus.GaugeValue, age)
+	}
+}
+
+func execute(name string, c Collector, ch chan<- prometheus.Metric) {
+	begin := time.Now()
+	err := c.Update(ch)
+	duration := time.Since(begin)
+	var success float64
+
+	if err != nil {
+		klog.Errorf("collector %s failed after %fs: %v", name, duration.Seconds(), err)
+		success = 0
+	} else {
+		success = 1
+	}
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+}
+
+// lvKey identifies a logical volume by its vg and lv names.
+type lvKey struct {
+	vg   string
+	name string
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// collectStateSet sends one series per possible value of a state set, the
+// value of the series matching current being 1 and the others 0. The
+// state is appended to the given label values.
+func collectStateSet(ch chan<- prometheus.Metric, desc *prometheus.Desc, values []string, current string, labels []string) {
+	for _, value := range values {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, boolToFloat64(value == current), append(labels[:len(labels):len(labels)], value)...)
+	}
+}
+
+// newEnumDesc returns the descriptor of a metric backed by lvm.Enums, which
+// gets the state and raw labels when enums are exposed as state sets.
+func newEnumDesc(fqName, help, stateSetHelp string, labels []string) *prometheus.Desc {
+	if *enumStateSets {
+		stateSetHelp += `, a value missing from the known states being reported as state "other" with the value as raw`
+		return prometheus.NewDesc(fqName, stateSetHelp, append(labels[:len(labels):len(labels)], "state", "raw"), nil)
+	}
+	return prometheus.NewDesc(fqName, help, labels, nil)
+}
+
+// collectEnum sends a metric created by newEnumDesc for the given lvm.Enums
+// field. As a state set, a value missing from lvm.Enums is reported by an
+// other state carrying the raw value, unknown being a real lvm value, an
+// empty one by no state at all unless lvm.Enums lists it, in which case
+// its state is none.
+func collectEnum(ch chan<- prometheus.Metric, desc *prometheus.Desc, field string, index int, raw string, labels []string) {
+	if !*enumStateSets {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(index), labels...)
+		return
+	}
+	n := len(labels)
+	labels = append(labels[:n:n], "", "")
+	for _, value := range lvm.Enums[field] {
+		labels[n] = value
+		if value == "" {
+			labels[n] = "none"
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, boolToFloat64(value == raw), labels...)
+	}
+	if index < 0 && raw != "" {
+		labels[n], labels[n+1] = "other", raw
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, labels...)
+	}
+}
+
+// ValidateTagLabels drops the repeated --collector.tag-label keys and fails
+// when two different keys would be exposed as the same label. It must be
+// called once the flags are parsed, before any collector is created.
+func ValidateTagLabels() error {
+	var keys []string
+	labels := make(map[string]string)
+	for _, key := range *tagLabels {
+		label := tagLabelName(key)
+		if prev, ok := labels[label]; ok {
+			if prev != key {
+				return fmt.Errorf("tag keys %q and %q are both exposed as label %s", prev, key, label)
+			}
+			continue
+		}
+		labels[label] = key
+		keys = append(keys, key)
+	}
+	*tagLabels = keys
+	return nil
+}
+
+// tagLabelName returns the label the tag key is exposed as.
+func tagLabelName(key string) string {
+	return "tag_" + invalidLabelChars.ReplaceAllString(key, "_")
+}
+
+// tagLabelNames returns the labels the tags promoted by --collector.tag-label
+// are exposed as.
+func tagLabelNames() []string {
+	var names []string
+	for _, key := range *tagLabels {
+		names = append(names, tagLabelName(key))
+	}
+	return names
+}
+
+// tagLabelValues returns the values of the labels given by tagLabelNames,
+// "" for a key none of the tags has.
+func tagLabelValues(tags []string) []string {
+	values := make([]string, len(*tagLabels))
+	for i, key := range *tagLabels {
+		for _, tag := range tags {
+			if strings.HasPrefix(tag, key+"=") {
+				values[i] = strings.TrimPrefix(tag, key+"=")
+				break
+			}
+		}
+	}
+	return values
+}
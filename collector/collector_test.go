@@ -0,0 +1,377 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixturesDir holds the recorded output of the lvm tools, see
+// lvm.FixtureRunner.
+const fixturesDir = "../testdata"
+
+func TestMain(m *testing.M) {
+	// The flags only get their defaults once parsed.
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		panic(err)
+	}
+	lvm.SetRunner(lvm.FixtureRunner{Dir: fixturesDir})
+	os.Exit(m.Run())
+}
+
+// testCollector exposes a Collector as a prometheus.Collector, without
+// the scrape duration and success metrics added by LvmCollector.
+type testCollector struct {
+	Collector
+}
+
+func (c testCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		panic(err)
+	}
+}
+
+func newTestScrape() *Scrape {
+	return NewScrape(context.Background(), NewLiveSource())
+}
+
+// useFixtures makes the lvm package replay the recorded lvm report
+// along with the given outputs, keyed by fixture file name, until the
+// returned function is called.
+func useFixtures(t *testing.T, outputs map[string]string) func() {
+	dir, err := ioutil.TempDir("", "lvm-fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"lvm_fullreport.stdout", "pvscan.stdout"} {
+		raw, err := ioutil.ReadFile(filepath.Join(fixturesDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, output := range outputs {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(output), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lvm.SetRunner(lvm.FixtureRunner{Dir: dir})
+	return func() {
+		lvm.SetRunner(lvm.FixtureRunner{Dir: fixturesDir})
+		os.RemoveAll(dir)
+	}
+}
+
+func TestNewLvmCollectorFilters(t *testing.T) {
+	l, err := NewLvmCollector(newTestScrape(), "vg", "thinpool")
+	if err != nil {
+		t.Fatalf("NewLvmCollector: %v", err)
+	}
+	if len(l.Collectors) != 2 {
+		t.Errorf("got %d collectors, want vg and thinpool", len(l.Collectors))
+	}
+	if _, err := NewLvmCollector(newTestScrape(), "forecast"); err == nil {
+		t.Error("got no error for the disabled forecast collector")
+	}
+	if _, err := NewLvmCollector(newTestScrape(), "nope"); err == nil {
+		t.Error("got no error for a missing collector")
+	}
+}
+
+func TestThinPoolCollector(t *testing.T) {
+	c := testCollector{NewThinPoolCollector(newTestScrape())}
+	want := `
+# HELP lvm_thinpool_data_total_blocks Total number of data blocks of the thin pool
+# TYPE lvm_thinpool_data_total_blocks gauge
+lvm_thinpool_data_total_blocks{name="pool",vg="vg0"} 1000
+# HELP lvm_thinpool_data_used_blocks Number of data blocks in use in the thin pool
+# TYPE lvm_thinpool_data_used_blocks gauge
+lvm_thinpool_data_used_blocks{name="pool",vg="vg0"} 500
+# HELP lvm_thinpool_discard_passdown Whether the thin pool passes discards down to its data device: [0: no], [1: yes]
+# TYPE lvm_thinpool_discard_passdown gauge
+lvm_thinpool_discard_passdown{name="pool",vg="vg0"} 1
+# HELP lvm_thinpool_held_mda_root Location of the held metadata snapshot root of the thin pool: [-1: none]
+# TYPE lvm_thinpool_held_mda_root gauge
+lvm_thinpool_held_mda_root{name="pool",vg="vg0"} -1
+# HELP lvm_thinpool_mda_total_blocks Total number of metadata blocks of the thin pool
+# TYPE lvm_thinpool_mda_total_blocks gauge
+lvm_thinpool_mda_total_blocks{name="pool",vg="vg0"} 1024
+# HELP lvm_thinpool_mda_used_blocks Number of metadata blocks in use in the thin pool
+# TYPE lvm_thinpool_mda_used_blocks gauge
+lvm_thinpool_mda_used_blocks{name="pool",vg="vg0"} 10
+# HELP lvm_thinpool_mode Thin pool mode: [-1: undefined], [0: rw], [1: ro], [2: out_of_data_space], [3: fail]
+# TYPE lvm_thinpool_mode gauge
+lvm_thinpool_mode{name="pool",vg="vg0"} 0
+# HELP lvm_thinpool_needs_check Whether the thin pool metadata needs to be checked: [0: no], [1: yes]
+# TYPE lvm_thinpool_needs_check gauge
+lvm_thinpool_needs_check{name="pool",vg="vg0"} 0
+# HELP lvm_thinpool_overcommit_ratio Ratio of the provisioned size of the thin pool to its size
+# TYPE lvm_thinpool_overcommit_ratio gauge
+lvm_thinpool_overcommit_ratio{name="pool",vg="vg0"} 5
+# HELP lvm_thinpool_provisioned_bytes Sum of the virtual sizes of the thin volumes of the thin pool in bytes
+# TYPE lvm_thinpool_provisioned_bytes gauge
+lvm_thinpool_provisioned_bytes{name="pool",vg="vg0"} 2.147483648e+10
+# HELP lvm_thinpool_thin_volume_count Number of thin volumes in the thin pool
+# TYPE lvm_thinpool_thin_volume_count gauge
+lvm_thinpool_thin_volume_count{name="pool",vg="vg0"} 2
+# HELP lvm_thinpool_transaction_id Transaction id of the thin pool metadata
+# TYPE lvm_thinpool_transaction_id gauge
+lvm_thinpool_transaction_id{name="pool",vg="vg0"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestThinPoolCollectorWithoutTpoolLayer(t *testing.T) {
+	// A pool without thin volumes may be activated without its -tpool
+	// layer, the pool device itself then running the thin-pool target.
+	defer useFixtures(t, map[string]string{
+		"dmsetup_status.stdout": "vg0-pool: 0 8388608 thin-pool 1 10/1024 600/1000 - rw discard_passdown queue_if_no_space - 1024\n",
+	})()
+
+	c := testCollector{NewThinPoolCollector(newTestScrape())}
+	want := `
+# HELP lvm_thinpool_data_used_blocks Number of data blocks in use in the thin pool
+# TYPE lvm_thinpool_data_used_blocks gauge
+lvm_thinpool_data_used_blocks{name="pool",vg="vg0"} 600
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "lvm_thinpool_data_used_blocks"); err != nil {
+		t.Error(err)
+	}
+}
+
+const raidImageMetrics = `
+# HELP lvm_raid_failed_image_count Number of images of the raid LV which have failed
+# TYPE lvm_raid_failed_image_count gauge
+lvm_raid_failed_image_count{name="r1",segtype="raid1",vg="vg0"} %d
+# HELP lvm_raid_healthy_image_count Number of images of the raid LV which are alive and in sync
+# TYPE lvm_raid_healthy_image_count gauge
+lvm_raid_healthy_image_count{name="r1",segtype="raid1",vg="vg0"} 1
+# HELP lvm_raid_image_count Number of images of the raid LV
+# TYPE lvm_raid_image_count gauge
+lvm_raid_image_count{name="r1",segtype="raid1",vg="vg0"} 2
+`
+
+func TestRaidCollector(t *testing.T) {
+	c := testCollector{NewRaidCollector(newTestScrape())}
+	want := fmt.Sprintf(raidImageMetrics, 0) + `
+# HELP lvm_raid_mismatch_count Number of differences found in the last scrubbing of the raid LV
+# TYPE lvm_raid_mismatch_count gauge
+lvm_raid_mismatch_count{name="r1",segtype="raid1",vg="vg0"} 0
+# HELP lvm_raid_sync_percent Percentage of the raid LV which is in sync
+# TYPE lvm_raid_sync_percent gauge
+lvm_raid_sync_percent{name="r1",segtype="raid1",vg="vg0"} 100
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"lvm_raid_failed_image_count", "lvm_raid_healthy_image_count", "lvm_raid_image_count",
+		"lvm_raid_mismatch_count", "lvm_raid_sync_percent",
+	); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRaidCollectorWithoutDMStatus(t *testing.T) {
+	// Without the dm status the health of the images is read from their
+	// sub LVs, r1_rmeta_1 having failed.
+	defer useFixtures(t, nil)()
+
+	c := testCollector{NewRaidCollector(newTestScrape())}
+	want := fmt.Sprintf(raidImageMetrics, 1)
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"lvm_raid_failed_image_count", "lvm_raid_healthy_image_count", "lvm_raid_image_count",
+	); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLvCollectorAttr(t *testing.T) {
+	c := testCollector{NewLvCollector(newTestScrape())}
+	want := `
+# HELP lvm_lv_open Whether the LVM LV device is open
+# TYPE lvm_lv_open gauge
+lvm_lv_open{name="cached",uuid="u-cached",vg="vg0"} 0
+lvm_lv_open{name="pool",uuid="u-pool",vg="vg0"} 1
+lvm_lv_open{name="r1",uuid="u-r1",vg="vg0"} 0
+lvm_lv_open{name="snap1",uuid="u-snap1",vg="vg0"} 0
+lvm_lv_open{name="thin1",uuid="u-thin1",vg="vg0"} 1
+# HELP lvm_lv_skip_activation Whether the LVM LV is skipped on activation
+# TYPE lvm_lv_skip_activation gauge
+lvm_lv_skip_activation{name="cached",uuid="u-cached",vg="vg0"} 0
+lvm_lv_skip_activation{name="pool",uuid="u-pool",vg="vg0"} 0
+lvm_lv_skip_activation{name="r1",uuid="u-r1",vg="vg0"} 0
+lvm_lv_skip_activation{name="snap1",uuid="u-snap1",vg="vg0"} 1
+lvm_lv_skip_activation{name="thin1",uuid="u-thin1",vg="vg0"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "lvm_lv_open", "lvm_lv_skip_activation"); err != nil {
+		t.Error(err)
+	}
+}
+
+// collectorFunc is a prometheus.Collector sending the metrics of a func.
+type collectorFunc func(ch chan<- prometheus.Metric)
+
+func (f collectorFunc) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(f, ch)
+}
+
+func (f collectorFunc) Collect(ch chan<- prometheus.Metric) {
+	f(ch)
+}
+
+func TestCollectEnumStateSet(t *testing.T) {
+	defer func(stateSets bool) { *enumStateSets = stateSets }(*enumStateSets)
+	*enumStateSets = true
+
+	desc := newEnumDesc("lvm_thinpool_mode", "Thin pool mode", "Thin pool mode, 1 for the current state", []string{"name"})
+	c := collectorFunc(func(ch chan<- prometheus.Metric) {
+		collectEnum(ch, desc, "thin_pool_mode", 1, "ro", []string{"pool"})
+		collectEnum(ch, desc, "thin_pool_mode", -1, "needs_repair", []string{"other"})
+	})
+	want := `
+# HELP lvm_thinpool_mode Thin pool mode, 1 for the current state, a value missing from the known states being reported as state "other" with the value as raw
+# TYPE lvm_thinpool_mode gauge
+lvm_thinpool_mode{name="pool",raw="",state="fail"} 0
+lvm_thinpool_mode{name="pool",raw="",state="out_of_data_space"} 0
+lvm_thinpool_mode{name="pool",raw="",state="ro"} 1
+lvm_thinpool_mode{name="pool",raw="",state="rw"} 0
+lvm_thinpool_mode{name="other",raw="",state="fail"} 0
+lvm_thinpool_mode{name="other",raw="",state="out_of_data_space"} 0
+lvm_thinpool_mode{name="other",raw="",state="ro"} 0
+lvm_thinpool_mode{name="other",raw="",state="rw"} 0
+lvm_thinpool_mode{name="other",raw="needs_repair",state="other"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPvCollectorInfoMetrics(t *testing.T) {
+	defer func(info bool) { *infoMetrics = info }(*infoMetrics)
+	*infoMetrics = true
+
+	c := testCollector{NewPvCollector(newTestScrape())}
+	want := `
+# HELP lvm_pv_info LVM PV descriptive labels, the other PV metrics being keyed by vg, name and uuid
+# TYPE lvm_pv_info gauge
+lvm_pv_info{allocatable="",in_use="",missing="",name="/dev/sdd",uuid="u-pv3",vg=""} 1
+lvm_pv_info{allocatable="allocatable",in_use="used",missing="",name="/dev/sdb",uuid="u-pv1",vg="vg0"} 1
+lvm_pv_info{allocatable="allocatable",in_use="used",missing="",name="/dev/sdc",uuid="u-pv2",vg="vg0"} 1
+# HELP lvm_pv_total_size_bytes LVM PV total size in bytes
+# TYPE lvm_pv_total_size_bytes gauge
+lvm_pv_total_size_bytes{name="/dev/sdb",uuid="u-pv1",vg="vg0"} 5.36870912e+09
+lvm_pv_total_size_bytes{name="/dev/sdc",uuid="u-pv2",vg="vg0"} 5.36870912e+09
+lvm_pv_total_size_bytes{name="/dev/sdd",uuid="u-pv3",vg=""} 1.073741824e+09
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "lvm_pv_info", "lvm_pv_total_size_bytes"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestVgCollectorGeometry(t *testing.T) {
+	c := testCollector{NewVgCollector(newTestScrape())}
+	want := `
+# HELP lvm_vg_extent_count Total number of physical extents of the VG
+# TYPE lvm_vg_extent_count gauge
+lvm_vg_extent_count{name="vg0"} 2560
+# HELP lvm_vg_extent_size_bytes Size of the physical extents of the VG in bytes
+# TYPE lvm_vg_extent_size_bytes gauge
+lvm_vg_extent_size_bytes{name="vg0"} 4.194304e+06
+# HELP lvm_vg_free_extent_count Number of unallocated physical extents of the VG
+# TYPE lvm_vg_free_extent_count gauge
+lvm_vg_free_extent_count{name="vg0"} 699
+# HELP lvm_vg_info VG system ID and lock type, empty for a VG with no owner or a local VG
+# TYPE lvm_vg_info gauge
+lvm_vg_info{lock_type="",name="vg0",systemid=""} 1
+# HELP lvm_vg_shared Whether the VG is shared with lvmlockd
+# TYPE lvm_vg_shared gauge
+lvm_vg_shared{name="vg0"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"lvm_vg_extent_count", "lvm_vg_extent_size_bytes", "lvm_vg_free_extent_count",
+		"lvm_vg_info", "lvm_vg_shared"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateTagLabels(t *testing.T) {
+	defer func(keys []string) { *tagLabels = keys }(*tagLabels)
+
+	*tagLabels = []string{"tenant", "team.name", "tenant"}
+	if err := ValidateTagLabels(); err != nil {
+		t.Fatalf("ValidateTagLabels: %v", err)
+	}
+	want := []string{"tag_tenant", "tag_team_name"}
+	if got := tagLabelNames(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got labels %v, want %v", got, want)
+	}
+	if got := tagLabelValues([]string{"backup", "tenant=x"}); got[0] != "x" || got[1] != "" {
+		t.Errorf("got values %q, want x and none", got)
+	}
+
+	*tagLabels = []string{"team.name", "team-name"}
+	if err := ValidateTagLabels(); err == nil {
+		t.Error("got no error for keys both exposed as tag_team_name")
+	}
+}
+
+func TestLvCollectorCreation(t *testing.T) {
+	// snap1 was created at 10:00 +0200, i.e. 08:00 UTC, on another host.
+	c := testCollector{NewLvCollector(newTestScrape())}
+	want := `
+# HELP lvm_lv_creation_timestamp_seconds Creation time of the LVM LV in seconds since the epoch
+# TYPE lvm_lv_creation_timestamp_seconds gauge
+lvm_lv_creation_timestamp_seconds{name="cached",vg="vg0"} 1.6253064e+09
+lvm_lv_creation_timestamp_seconds{name="pool",vg="vg0"} 1.6251336e+09
+lvm_lv_creation_timestamp_seconds{name="r1",vg="vg0"} 1.6251336e+09
+lvm_lv_creation_timestamp_seconds{name="snap1",vg="vg0"} 1.6252128e+09
+lvm_lv_creation_timestamp_seconds{name="thin1",vg="vg0"} 1.6251336e+09
+# HELP lvm_lv_host_info Host which created the LVM LV
+# TYPE lvm_lv_host_info gauge
+lvm_lv_host_info{host="node1",name="cached",vg="vg0"} 1
+lvm_lv_host_info{host="node1",name="pool",vg="vg0"} 1
+lvm_lv_host_info{host="node1",name="r1",vg="vg0"} 1
+lvm_lv_host_info{host="node1",name="thin1",vg="vg0"} 1
+lvm_lv_host_info{host="node2",name="snap1",vg="vg0"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "lvm_lv_creation_timestamp_seconds", "lvm_lv_host_info"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAutoextendCollector(t *testing.T) {
+	c := testCollector{NewAutoextendCollector(newTestScrape())}
+	want := `
+# HELP lvm_config_autoextend_percent Percent of their size by which dmeventd extends the thin pools or snapshots
+# TYPE lvm_config_autoextend_percent gauge
+lvm_config_autoextend_percent{type="snapshot"} 20
+lvm_config_autoextend_percent{type="thin_pool"} 20
+# HELP lvm_config_autoextend_threshold_percent Usage percent above which dmeventd extends the thin pools or snapshots, 100 if they are not extended
+# TYPE lvm_config_autoextend_threshold_percent gauge
+lvm_config_autoextend_threshold_percent{type="snapshot"} 100
+lvm_config_autoextend_threshold_percent{type="thin_pool"} 70
+# HELP lvm_config_monitoring Whether the LVs are monitored by dmeventd when activated
+# TYPE lvm_config_monitoring gauge
+lvm_config_monitoring 1
+# HELP lvm_lv_monitored Whether the LVM LV is monitored by dmeventd, only reported for the LVs which can be
+# TYPE lvm_lv_monitored gauge
+lvm_lv_monitored{name="pool",vg="vg0"} 1
+lvm_lv_monitored{name="r1",vg="vg0"} 1
+# HELP lvm_thinpool_autoextend_headroom_bytes Data which can still be written to the monitored LVM thin pool before it reaches its autoextend threshold and the VG lacks the free space to extend it, in bytes
+# TYPE lvm_thinpool_autoextend_headroom_bytes gauge
+lvm_thinpool_autoextend_headroom_bytes{name="pool",vg="vg0"} 2.183135232e+09
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
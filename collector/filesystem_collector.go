@@ -0,0 +1,176 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"io/ioutil"
+	"k8s.io/klog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var (
+	mountinfoPath = kingpin.Flag("collector.filesystem.mountinfo",
+		"Path of the mountinfo file mapping the logical volumes to their mount points.",
+	).Default("/proc/self/mountinfo").String()
+	filesystemRootPrefix = kingpin.Flag("collector.filesystem.root-prefix",
+		"Prefix of the mount points when running statfs, e.g. where the host root is mounted in the container.",
+	).Default("").String()
+)
+
+// filesystemCollector exposes the usage of the filesystems held by the
+// active logical volumes.
+type filesystemCollector struct {
+	scrape *Scrape
+
+	fsSizeMetric      *prometheus.Desc
+	fsFreeMetric      *prometheus.Desc
+	fsAvailMetric     *prometheus.Desc
+	fsFilesMetric     *prometheus.Desc
+	fsFilesFreeMetric *prometheus.Desc
+}
+
+func init() {
+	registerCollector("filesystem", defaultDisabled, func(scrape *Scrape) Collector {
+		return NewFilesystemCollector(scrape)
+	})
+}
+
+// NewFilesystemCollector initializes every descriptor and returns a pointer to the collector
+func NewFilesystemCollector(scrape *Scrape) *filesystemCollector {
+	return &filesystemCollector{
+		scrape: scrape,
+		fsSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "filesystem", "size_bytes"),
+			"Size of the filesystem of the LV in bytes",
+			[]string{"name", "vg", "mountpoint", "fstype"}, nil,
+		),
+		fsFreeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "filesystem", "free_bytes"),
+			"Free space of the filesystem of the LV in bytes",
+			[]string{"name", "vg", "mountpoint", "fstype"}, nil,
+		),
+		fsAvailMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "filesystem", "avail_bytes"),
+			"Space of the filesystem of the LV available to non-root users in bytes",
+			[]string{"name", "vg", "mountpoint", "fstype"}, nil,
+		),
+		fsFilesMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "filesystem", "files"),
+			"Total number of inodes of the filesystem of the LV",
+			[]string{"name", "vg", "mountpoint", "fstype"}, nil,
+		),
+		fsFilesFreeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "filesystem", "files_free"),
+			"Number of free inodes of the filesystem of the LV",
+			[]string{"name", "vg", "mountpoint", "fstype"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *filesystemCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.fsSizeMetric
+	ch <- collector.fsFreeMetric
+	ch <- collector.fsAvailMetric
+	ch <- collector.fsFilesMetric
+	ch <- collector.fsFilesFreeMetric
+}
+
+// Update implements the Collector interface, it fails when the list of
+// lvm logical volumes or the mount points cannot be fetched. A filesystem
+// which cannot be stat'ed is logged and skipped.
+func (collector *filesystemCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the list of lvm logical volumes: %w", err)
+	}
+	mounts, err := readMountinfo(*mountinfoPath)
+	if err != nil {
+		return fmt.Errorf("error in getting the mount points: %w", err)
+	}
+
+	for _, lv := range report.LogicalVolumes {
+		if lv.KernelMajor < 0 {
+			continue
+		}
+		m, ok := mounts[fmt.Sprintf("%d:%d", lv.KernelMajor, lv.KernelMinor)]
+		if !ok {
+			continue
+		}
+		var fs syscall.Statfs_t
+		if err := syscall.Statfs(filepath.Join(*filesystemRootPrefix, m.mountPoint), &fs); err != nil {
+			klog.Errorf("error in getting the usage of the filesystem of lv %s/%s on %s: %v", lv.VGName, lv.Name, m.mountPoint, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(collector.fsSizeMetric, prometheus.GaugeValue, float64(fs.Blocks)*float64(fs.Bsize), lv.Name, lv.VGName, m.mountPoint, m.fsType)
+		ch <- prometheus.MustNewConstMetric(collector.fsFreeMetric, prometheus.GaugeValue, float64(fs.Bfree)*float64(fs.Bsize), lv.Name, lv.VGName, m.mountPoint, m.fsType)
+		ch <- prometheus.MustNewConstMetric(collector.fsAvailMetric, prometheus.GaugeValue, float64(fs.Bavail)*float64(fs.Bsize), lv.Name, lv.VGName, m.mountPoint, m.fsType)
+		ch <- prometheus.MustNewConstMetric(collector.fsFilesMetric, prometheus.GaugeValue, float64(fs.Files), lv.Name, lv.VGName, m.mountPoint, m.fsType)
+		ch <- prometheus.MustNewConstMetric(collector.fsFilesFreeMetric, prometheus.GaugeValue, float64(fs.Ffree), lv.Name, lv.VGName, m.mountPoint, m.fsType)
+	}
+	return nil
+}
+
+// mount is a line of mountinfo.
+type mount struct {
+	root       string
+	mountPoint string
+	fsType     string
+}
+
+// readMountinfo returns the mount of every device of the mountinfo file,
+// keyed by <major>:<minor>. A device mounted several times is reported by
+// the first mount of its root directory, bind mounts of its subdirectories
+// only being used when the root is not mounted.
+func readMountinfo(path string) (map[string]mount, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mounts := make(map[string]mount)
+	for _, line := range strings.Split(string(raw), "\n") {
+		// <id> <parent id> <major>:<minor> <root> <mount point> <options>
+		// [<optional fields>...] - <fstype> <source> <super options>
+		fields := strings.Fields(line)
+		sep := -1
+		for i, field := range fields {
+			if field == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 6 || sep+1 >= len(fields) {
+			continue
+		}
+		m := mount{
+			root:       unescapeMountinfo(fields[3]),
+			mountPoint: unescapeMountinfo(fields[4]),
+			fsType:     fields[sep+1],
+		}
+		if prev, ok := mounts[fields[2]]; ok && (prev.root == "/" || m.root != "/") {
+			continue
+		}
+		mounts[fields[2]] = m
+	}
+	return mounts, nil
+}
+
+// unescapeMountinfo decodes the octal escapes, like \040 for a space,
+// of the paths of mountinfo.
+func unescapeMountinfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestReadMountinfo(t *testing.T) {
+	mounts, err := readMountinfo(fixturesDir + "/mountinfo")
+	if err != nil {
+		t.Fatalf("readMountinfo: %v", err)
+	}
+	want := map[string]mount{
+		"253:9": {root: "/", mountPoint: "/", fsType: "ext4"},
+		// The root of the thin volume is preferred to its bind mount.
+		"253:4": {root: "/", mountPoint: "/mnt/my data", fsType: "xfs"},
+		"0:21":  {root: "/", mountPoint: "/proc", fsType: "proc"},
+	}
+	if len(mounts) != len(want) {
+		t.Errorf("got %d devices, want %d", len(mounts), len(want))
+	}
+	for dev, m := range want {
+		if mounts[dev] != m {
+			t.Errorf("got %+v for %s, want %+v", mounts[dev], dev, m)
+		}
+	}
+
+	if _, err := readMountinfo(fixturesDir + "/missing"); err == nil {
+		t.Error("got no error for a missing mountinfo")
+	}
+}
+
+func TestUnescapeMountinfo(t *testing.T) {
+	tests := map[string]string{
+		`/mnt/data`:         "/mnt/data",
+		`/mnt/my\040data`:   "/mnt/my data",
+		`/mnt/a\011b\134c`:  "/mnt/a\tb\\c",
+		`/mnt/trailing\04`:  `/mnt/trailing\04`,
+		`/mnt/not\999octal`: `/mnt/not\999octal`,
+	}
+	for s, want := range tests {
+		if got := unescapeMountinfo(s); got != want {
+			t.Errorf("got %q for %q, want %q", got, s, want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"io/ioutil"
+	"k8s.io/klog"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	forecastWindow = kingpin.Flag("collector.forecast.window",
+		"Period of the samples the forecasts are fitted on.",
+	).Default("24h").Duration()
+	forecastSampleInterval = kingpin.Flag("collector.forecast.sample-interval",
+		"Minimum time between two samples of the forecasts, bounding their number whatever the scrape interval.",
+	).Default("1m").Duration()
+	forecastMinSamples = kingpin.Flag("collector.forecast.min-samples",
+		"Minimum number of samples in the window before forecasting.",
+	).Default("10").Int()
+	forecastStateFile = kingpin.Flag("collector.forecast.state-file",
+		"File keeping the samples of the forecasts across restarts, none if empty.",
+	).Default("").String()
+)
+
+// forecastSample is the value of a forecast series at a given time.
+type forecastSample struct {
+	Time  int64   `json:"t"`
+	Value float64 `json:"v"`
+}
+
+// forecastState holds the samples of the forecasts, the free bytes of the
+// volume groups keyed by vg name and the free data percent, i.e. 100 minus
+// data_percent, of the thin pools keyed by vg/pool.
+type forecastState struct {
+	VolumeGroups map[string][]forecastSample `json:"vg"`
+	ThinPools    map[string][]forecastSample `json:"thinpool"`
+}
+
+// forecaster keeps the samples across scrapes, every scrape having its
+// own collectors.
+var forecaster = struct {
+	sync.Mutex
+	loaded bool
+	state  forecastState
+}{}
+
+// forecastCollector forecasts when the volume groups and the thin pools
+// will be full from the linear trend of their usage over a rolling window.
+type forecastCollector struct {
+	scrape *Scrape
+
+	vgPredictedFullMetric *prometheus.Desc
+	tpPredictedFullMetric *prometheus.Desc
+}
+
+func init() {
+	registerCollector("forecast", defaultDisabled, func(scrape *Scrape) Collector {
+		return NewForecastCollector(scrape)
+	})
+}
+
+// NewForecastCollector initializes every descriptor and returns a pointer to the collector
+func NewForecastCollector(scrape *Scrape) *forecastCollector {
+	return &forecastCollector{
+		scrape: scrape,
+		vgPredictedFullMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "predicted_full_seconds"),
+			"Time until the LVM VG has no free space left at the linear trend of its free space over the forecast window in seconds, +Inf if it is not shrinking",
+			[]string{"name"}, nil,
+		),
+		tpPredictedFullMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "predicted_full_seconds"),
+			"Time until the data of the LVM thin pool is full at the linear trend of its data percent over the forecast window in seconds, +Inf if it is not growing",
+			[]string{"name", "vg"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *forecastCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.vgPredictedFullMetric
+	ch <- collector.tpPredictedFullMetric
+}
+
+// Update implements the Collector interface, it fails when the lvm report
+// cannot be fetched. The samples are only saved to the state file when
+// one was added, failing to do so being logged.
+func (collector *forecastCollector) Update(ch chan<- prometheus.Metric) error {
+	snapshot, err := collector.scrape.Snapshot()
+	if err != nil {
+		return fmt.Errorf("error in getting the lvm report: %w", err)
+	}
+	report := snapshot.Report
+
+	forecaster.Lock()
+	defer forecaster.Unlock()
+	if !forecaster.loaded {
+		forecaster.state = loadForecastState(*forecastStateFile)
+		forecaster.loaded = true
+	}
+	state := &forecaster.state
+
+	now := snapshot.Time
+	changed := false
+	vgs := make(map[string][]forecastSample)
+	for _, vg := range report.VolumeGroups {
+		samples, added := addForecastSample(state.VolumeGroups[vg.Name], now, vg.Free.AsApproximateFloat64())
+		changed = changed || added
+		vgs[vg.Name] = samples
+		if seconds, ok := predictFull(samples); ok {
+			ch <- prometheus.MustNewConstMetric(collector.vgPredictedFullMetric, prometheus.GaugeValue, seconds, vg.Name)
+		}
+	}
+	pools := make(map[string][]forecastSample)
+	for _, lv := range report.LogicalVolumes {
+		// The data percent of inactive pools is unknown.
+		if lv.SegType != lvm.LVThinPool || lv.Attr.State != "active" {
+			continue
+		}
+		key := lv.VGName + "/" + lv.Name
+		samples, added := addForecastSample(state.ThinPools[key], now, 100-lv.UsedSizePercent)
+		changed = changed || added
+		pools[key] = samples
+		if seconds, ok := predictFull(samples); ok {
+			ch <- prometheus.MustNewConstMetric(collector.tpPredictedFullMetric, prometheus.GaugeValue, seconds, lv.Name, lv.VGName)
+		}
+	}
+	// Forget the volume groups and pools which are gone.
+	changed = changed || len(vgs) != len(state.VolumeGroups) || len(pools) != len(state.ThinPools)
+	state.VolumeGroups = vgs
+	state.ThinPools = pools
+
+	if changed && *forecastStateFile != "" {
+		if err := saveForecastState(*forecastStateFile, state); err != nil {
+			klog.Errorf("error in saving the forecast state to %s: %v", *forecastStateFile, err)
+		}
+	}
+	return nil
+}
+
+// addForecastSample appends the value at now to samples unless the last
+// one is more recent than the sample interval, and drops the samples out
+// of the window. It tells whether the value was added.
+func addForecastSample(samples []forecastSample, now time.Time, value float64) ([]forecastSample, bool) {
+	added := false
+	if n := len(samples); n == 0 || now.Unix()-samples[n-1].Time >= int64(forecastSampleInterval.Seconds()) {
+		samples = append(samples, forecastSample{Time: now.Unix(), Value: value})
+		added = true
+	}
+	start := now.Add(-*forecastWindow).Unix()
+	i := 0
+	for i < len(samples) && samples[i].Time < start {
+		i++
+	}
+	return samples[i:], added
+}
+
+// predictFull fits a line to the samples of free space by least squares
+// and returns the time from the last sample until the line reaches 0,
+// +Inf when it does not decrease. It fails when there are not enough
+// samples.
+func predictFull(samples []forecastSample) (float64, bool) {
+	n := len(samples)
+	if n < 2 || n < *forecastMinSamples {
+		return 0, false
+	}
+	// Center the times on the last sample, the intercept then being the
+	// free space fitted at that time.
+	last := samples[n-1].Time
+	var sumX, sumY, sumXX, sumXY float64
+	for _, s := range samples {
+		x := float64(s.Time - last)
+		sumX += x
+		sumY += s.Value
+		sumXX += x * x
+		sumXY += x * s.Value
+	}
+	d := float64(n)*sumXX - sumX*sumX
+	if d == 0 {
+		return 0, false
+	}
+	slope := (float64(n)*sumXY - sumX*sumY) / d
+	intercept := (sumY - slope*sumX) / float64(n)
+
+	switch {
+	case intercept <= 0:
+		return 0, true
+	case slope >= 0:
+		return math.Inf(1), true
+	}
+	return intercept / -slope, true
+}
+
+// loadForecastState reads the samples saved to path, starting afresh when
+// there is none or they cannot be read.
+func loadForecastState(path string) forecastState {
+	var state forecastState
+	if path == "" {
+		return state
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			klog.Errorf("error in reading the forecast state from %s: %v", path, err)
+		}
+		return state
+	}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		klog.Errorf("error in parsing the forecast state from %s: %v", path, err)
+		return forecastState{}
+	}
+	return state
+}
+
+// saveForecastState writes the samples to path through a temporary file,
+// so that a crash never leaves a truncated state behind.
+func saveForecastState(path string, state *forecastState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// forecastSamples returns a sample of each value, one sample interval
+// apart.
+func forecastSamples(values ...float64) []forecastSample {
+	var samples []forecastSample
+	for i, value := range values {
+		samples = append(samples, forecastSample{Time: int64(i) * 60, Value: value})
+	}
+	return samples
+}
+
+func TestPredictFull(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []forecastSample
+		want    float64
+		wantOk  bool
+	}{
+		{
+			name:    "decreasing",
+			samples: forecastSamples(1000, 900, 800, 700, 600, 500, 400, 300, 200, 100),
+			// 100 left at 100 per minute.
+			want:   60,
+			wantOk: true,
+		},
+		{
+			name:    "flat",
+			samples: forecastSamples(500, 500, 500, 500, 500, 500, 500, 500, 500, 500),
+			want:    math.Inf(1),
+			wantOk:  true,
+		},
+		{
+			name:    "increasing",
+			samples: forecastSamples(100, 200, 300, 400, 500, 600, 700, 800, 900, 1000),
+			want:    math.Inf(1),
+			wantOk:  true,
+		},
+		{
+			name:    "already full",
+			samples: forecastSamples(900, 800, 700, 600, 500, 400, 300, 200, 100, 0),
+			want:    0,
+			wantOk:  true,
+		},
+		{
+			name:    "too few samples",
+			samples: forecastSamples(1000, 900, 800),
+		},
+		{
+			name:    "same time",
+			samples: []forecastSample{{60, 100}, {60, 90}, {60, 80}, {60, 70}, {60, 60}, {60, 50}, {60, 40}, {60, 30}, {60, 20}, {60, 10}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := predictFull(test.samples)
+			if ok != test.wantOk {
+				t.Fatalf("got ok %v, want %v", ok, test.wantOk)
+			}
+			if ok && got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAddForecastSample(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	samples, added := addForecastSample(nil, now, 10)
+	if !added || len(samples) != 1 {
+		t.Fatalf("got %d samples, added %v, want the first sample added", len(samples), added)
+	}
+
+	// Too close to the last sample.
+	samples, added = addForecastSample(samples, now.Add(*forecastSampleInterval/2), 20)
+	if added || len(samples) != 1 {
+		t.Errorf("got %d samples, added %v, want the sample skipped", len(samples), added)
+	}
+
+	samples, added = addForecastSample(samples, now.Add(*forecastSampleInterval), 30)
+	if !added || len(samples) != 2 {
+		t.Errorf("got %d samples, added %v, want the sample added", len(samples), added)
+	}
+
+	// The first samples fall out of the window.
+	samples, added = addForecastSample(samples, now.Add(*forecastWindow+time.Second), 40)
+	if !added || len(samples) != 2 || samples[0].Value != 30 || samples[1].Value != 40 {
+		t.Errorf("got samples %+v, want the first one dropped", samples)
+	}
+}
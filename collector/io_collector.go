@@ -0,0 +1,215 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"io/ioutil"
+	"k8s.io/klog"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The block layer counts in 512 bytes sectors whatever the device.
+	sectorSize = 512
+
+	// Number of the fields of the stat file of a block device up to
+	// time_in_queue, which every kernel reports.
+	blockStatFields = 11
+)
+
+// ioCollector exposes the I/O statistics of the device mapper devices of
+// the active logical volumes, as reported by the block layer in sysfs.
+type ioCollector struct {
+	scrape *Scrape
+
+	ioReadsCompletedMetric    *prometheus.Desc
+	ioReadsMergedMetric       *prometheus.Desc
+	ioReadBytesMetric         *prometheus.Desc
+	ioReadTimeMetric          *prometheus.Desc
+	ioWritesCompletedMetric   *prometheus.Desc
+	ioWritesMergedMetric      *prometheus.Desc
+	ioWrittenBytesMetric      *prometheus.Desc
+	ioWriteTimeMetric         *prometheus.Desc
+	ioTimeMetric              *prometheus.Desc
+	ioTimeWeightedMetric      *prometheus.Desc
+	ioDiscardsCompletedMetric *prometheus.Desc
+	ioDiscardedBytesMetric    *prometheus.Desc
+	ioInflightReadsMetric     *prometheus.Desc
+	ioInflightWritesMetric    *prometheus.Desc
+}
+
+func init() {
+	registerCollector("io", defaultDisabled, func(scrape *Scrape) Collector {
+		return NewIOCollector(scrape)
+	})
+}
+
+// NewIOCollector initializes every descriptor and returns a pointer to the collector
+func NewIOCollector(scrape *Scrape) *ioCollector {
+	return &ioCollector{
+		scrape: scrape,
+		ioReadsCompletedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "reads_completed_total"),
+			"Number of reads completed on the LV device",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioReadsMergedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "reads_merged_total"),
+			"Number of adjacent reads merged on the LV device",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioReadBytesMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "read_bytes_total"),
+			"Number of bytes read from the LV device",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioReadTimeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "read_time_seconds_total"),
+			"Time spent by the reads of the LV device in seconds",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioWritesCompletedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "writes_completed_total"),
+			"Number of writes completed on the LV device",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioWritesMergedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "writes_merged_total"),
+			"Number of adjacent writes merged on the LV device",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioWrittenBytesMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "written_bytes_total"),
+			"Number of bytes written to the LV device",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioWriteTimeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "write_time_seconds_total"),
+			"Time spent by the writes of the LV device in seconds",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioTimeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "time_seconds_total"),
+			"Time the LV device had I/Os in progress in seconds",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioTimeWeightedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "time_weighted_seconds_total"),
+			"Time spent by the I/Os of the LV device weighted by the number of I/Os in progress in seconds",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioDiscardsCompletedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "discards_completed_total"),
+			"Number of discards completed on the LV device, on kernels reporting them",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioDiscardedBytesMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "discarded_bytes_total"),
+			"Number of bytes discarded on the LV device, on kernels reporting them",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioInflightReadsMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "inflight_reads"),
+			"Number of reads in progress on the LV device",
+			[]string{"name", "vg", "device"}, nil,
+		),
+		ioInflightWritesMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_io", "inflight_writes"),
+			"Number of writes in progress on the LV device",
+			[]string{"name", "vg", "device"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *ioCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.ioReadsCompletedMetric
+	ch <- collector.ioReadsMergedMetric
+	ch <- collector.ioReadBytesMetric
+	ch <- collector.ioReadTimeMetric
+	ch <- collector.ioWritesCompletedMetric
+	ch <- collector.ioWritesMergedMetric
+	ch <- collector.ioWrittenBytesMetric
+	ch <- collector.ioWriteTimeMetric
+	ch <- collector.ioTimeMetric
+	ch <- collector.ioTimeWeightedMetric
+	ch <- collector.ioDiscardsCompletedMetric
+	ch <- collector.ioDiscardedBytesMetric
+	ch <- collector.ioInflightReadsMetric
+	ch <- collector.ioInflightWritesMetric
+}
+
+// Update implements the Collector interface, it fails when the list of
+// lvm logical volumes cannot be fetched. A device whose statistics cannot
+// be read, e.g. because it was just deactivated, is logged and skipped.
+func (collector *ioCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the list of lvm logical volumes: %w", err)
+	}
+	for _, lv := range report.LogicalVolumes {
+		// The I/O of the hidden sub LVs is already accounted for by the
+		// LVs they make up, thin pools and raid LVs.
+		if lv.Hidden {
+			continue
+		}
+		dir := lvBlockDir(lv)
+		if dir == "" {
+			continue
+		}
+		stats, err := readUintFields(sysFilePath(dir, "stat"))
+		if err != nil {
+			klog.Errorf("error in getting the I/O statistics of lv %s/%s: %v", lv.VGName, lv.Name, err)
+			continue
+		}
+		if len(stats) < blockStatFields {
+			klog.Errorf("error in getting the I/O statistics of lv %s/%s: expected %d fields, got %d", lv.VGName, lv.Name, blockStatFields, len(stats))
+			continue
+		}
+		inflight, err := readUintFields(sysFilePath(dir, "inflight"))
+		if err != nil || len(inflight) < 2 {
+			// The in-flight I/Os are only reported when available.
+			inflight = nil
+		}
+
+		ch <- prometheus.MustNewConstMetric(collector.ioReadsCompletedMetric, prometheus.CounterValue, stats[0], lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioReadsMergedMetric, prometheus.CounterValue, stats[1], lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioReadBytesMetric, prometheus.CounterValue, stats[2]*sectorSize, lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioReadTimeMetric, prometheus.CounterValue, stats[3]/1000, lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioWritesCompletedMetric, prometheus.CounterValue, stats[4], lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioWritesMergedMetric, prometheus.CounterValue, stats[5], lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioWrittenBytesMetric, prometheus.CounterValue, stats[6]*sectorSize, lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioWriteTimeMetric, prometheus.CounterValue, stats[7]/1000, lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioTimeMetric, prometheus.CounterValue, stats[9]/1000, lv.Name, lv.VGName, lv.Device)
+		ch <- prometheus.MustNewConstMetric(collector.ioTimeWeightedMetric, prometheus.CounterValue, stats[10]/1000, lv.Name, lv.VGName, lv.Device)
+		if len(stats) >= blockStatFields+4 {
+			ch <- prometheus.MustNewConstMetric(collector.ioDiscardsCompletedMetric, prometheus.CounterValue, stats[11], lv.Name, lv.VGName, lv.Device)
+			ch <- prometheus.MustNewConstMetric(collector.ioDiscardedBytesMetric, prometheus.CounterValue, stats[13]*sectorSize, lv.Name, lv.VGName, lv.Device)
+		}
+		if inflight != nil {
+			ch <- prometheus.MustNewConstMetric(collector.ioInflightReadsMetric, prometheus.GaugeValue, inflight[0], lv.Name, lv.VGName, lv.Device)
+			ch <- prometheus.MustNewConstMetric(collector.ioInflightWritesMetric, prometheus.GaugeValue, inflight[1], lv.Name, lv.VGName, lv.Device)
+		}
+	}
+	return nil
+}
+
+// lvBlockDir returns the sysfs directory of the block device of an active
+// logical volume relative to the sysfs mountpoint, "" if it has none.
+// When the device could not be resolved from the lv path, e.g. because
+// /dev is not visible, the device number is used instead.
+func lvBlockDir(lv lvm.LogicalVolume) string {
+	if lv.Device != "" {
+		return "block/" + lv.Device
+	}
+	if lv.KernelMajor > 0 {
+		return fmt.Sprintf("dev/block/%d:%d", lv.KernelMajor, lv.KernelMinor)
+	}
+	return ""
+}
+
+// readUintFields reads a sysfs file made of a single line of unsigned
+// integers separated by spaces.
+func readUintFields(path string) ([]float64, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values []float64
+	for _, field := range strings.Fields(string(raw)) {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid format of %v: %v", path, err)
+		}
+		values = append(values, float64(value))
+	}
+	return values, nil
+}
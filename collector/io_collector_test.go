@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useSysfs points --path.sysfs to a fake tree made of the given files,
+// keyed by path, and of the given symlinks, keyed by path to their target,
+// until the returned function is called.
+func useSysfs(t *testing.T, files, symlinks map[string]string) func() {
+	dir, err := ioutil.TempDir("", "sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, target := range symlinks {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prev := *sysPath
+	*sysPath = dir
+	return func() {
+		*sysPath = prev
+		os.RemoveAll(dir)
+	}
+}
+
+func TestIOCollector(t *testing.T) {
+	defer useSysfs(t, map[string]string{
+		// Kernels before 4.18 report 11 fields, later ones the discards
+		// too.
+		"block/dm-4/stat":     "100 1 800 20 200 2 1600 40 0 50 60\n",
+		"block/dm-4/inflight": "       3        4\n",
+		"block/dm-3/stat":     "10 0 80 2 20 0 160 4 0 5 6 7 0 56 8\n",
+		"block/dm-3/inflight": "0 1\n",
+		"block/dm-9/stat":     "30 0 240 3 40 0 320 5 0 6 7 0 0 0 0\n",
+		// The sub LVs of the thin pool.
+		"block/dm-0/stat": "1 0 8 1 1 0 8 1 0 1 1\n",
+		"block/dm-1/stat": "10 0 80 2 20 0 160 4 0 5 6\n",
+	}, map[string]string{
+		"dev/block/253:0": "../../block/dm-0",
+		"dev/block/253:1": "../../block/dm-1",
+		"dev/block/253:3": "../../block/dm-3",
+		"dev/block/253:4": "../../block/dm-4",
+		"dev/block/253:9": "../../block/dm-9",
+	})()
+
+	c := testCollector{NewIOCollector(newTestScrape())}
+	want := `
+# HELP lvm_lv_io_discards_completed_total Number of discards completed on the LV device, on kernels reporting them
+# TYPE lvm_lv_io_discards_completed_total counter
+lvm_lv_io_discards_completed_total{device="",name="pool",vg="vg0"} 7
+lvm_lv_io_discards_completed_total{device="",name="r1",vg="vg0"} 0
+# HELP lvm_lv_io_discarded_bytes_total Number of bytes discarded on the LV device, on kernels reporting them
+# TYPE lvm_lv_io_discarded_bytes_total counter
+lvm_lv_io_discarded_bytes_total{device="",name="pool",vg="vg0"} 28672
+lvm_lv_io_discarded_bytes_total{device="",name="r1",vg="vg0"} 0
+# HELP lvm_lv_io_inflight_reads Number of reads in progress on the LV device
+# TYPE lvm_lv_io_inflight_reads gauge
+lvm_lv_io_inflight_reads{device="",name="pool",vg="vg0"} 0
+lvm_lv_io_inflight_reads{device="",name="thin1",vg="vg0"} 3
+# HELP lvm_lv_io_read_bytes_total Number of bytes read from the LV device
+# TYPE lvm_lv_io_read_bytes_total counter
+lvm_lv_io_read_bytes_total{device="",name="pool",vg="vg0"} 40960
+lvm_lv_io_read_bytes_total{device="",name="r1",vg="vg0"} 122880
+lvm_lv_io_read_bytes_total{device="",name="thin1",vg="vg0"} 409600
+# HELP lvm_lv_io_reads_completed_total Number of reads completed on the LV device
+# TYPE lvm_lv_io_reads_completed_total counter
+lvm_lv_io_reads_completed_total{device="",name="pool",vg="vg0"} 10
+lvm_lv_io_reads_completed_total{device="",name="r1",vg="vg0"} 30
+lvm_lv_io_reads_completed_total{device="",name="thin1",vg="vg0"} 100
+# HELP lvm_lv_io_time_weighted_seconds_total Time spent by the I/Os of the LV device weighted by the number of I/Os in progress in seconds
+# TYPE lvm_lv_io_time_weighted_seconds_total counter
+lvm_lv_io_time_weighted_seconds_total{device="",name="pool",vg="vg0"} 0.006
+lvm_lv_io_time_weighted_seconds_total{device="",name="r1",vg="vg0"} 0.007
+lvm_lv_io_time_weighted_seconds_total{device="",name="thin1",vg="vg0"} 0.06
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"lvm_lv_io_discards_completed_total", "lvm_lv_io_discarded_bytes_total",
+		"lvm_lv_io_inflight_reads", "lvm_lv_io_read_bytes_total",
+		"lvm_lv_io_reads_completed_total", "lvm_lv_io_time_weighted_seconds_total",
+	); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,9 +1,9 @@
 package collector
 
 import (
+	"fmt"
 	"github.com/Ab-hishek/LVM-exporter/lvm"
 	"github.com/prometheus/client_golang/prometheus"
-	"k8s.io/klog"
 )
 
 // Define a struct for you collector that contains pointers
@@ -11,6 +11,13 @@ import (
 // Note you can also include fields of other types if they provide utility
 // but we just won't be exposing them as metrics.
 type lvCollector struct {
+	scrape *Scrape
+
+	lvInfoMetric              *prometheus.Desc
+	lvTagInfoMetric           *prometheus.Desc
+	lvHostInfoMetric          *prometheus.Desc
+	lvCreationTimestampMetric *prometheus.Desc
+
 	lvSizeMetric                *prometheus.Desc
 	lvUsedSizePercentMetric     *prometheus.Desc
 	lvPermissionMetric          *prometheus.Desc
@@ -20,47 +27,130 @@ type lvCollector struct {
 	lvMetadataSizeMetric        *prometheus.Desc
 	lvMetadataUsedPercentMetric *prometheus.Desc
 	lvSnapshotUsedPercentMetric *prometheus.Desc
+
+	// Decoded from lv_attr, keyed by vg, name and uuid only.
+	lvVolumeTypeMetric       *prometheus.Desc
+	lvAllocationPolicyMetric *prometheus.Desc
+	lvAllocationLockedMetric *prometheus.Desc
+	lvFixedMinorMetric       *prometheus.Desc
+	lvStateMetric            *prometheus.Desc
+	lvOpenMetric             *prometheus.Desc
+	lvTargetTypeMetric       *prometheus.Desc
+	lvZeroingMetric          *prometheus.Desc
+	lvHealthMetric           *prometheus.Desc
+	lvSkipActivationMetric   *prometheus.Desc
+}
+
+func init() {
+	registerCollector("lv", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewLvCollector(scrape)
+	})
 }
 
 // You must create a constructor for your collector that
 // initializes every descriptor and returns a pointer to the collector
-func NewLvCollector() *lvCollector {
+func NewLvCollector(scrape *Scrape) *lvCollector {
+	labels := lvLabelNames()
+	// The lv_attr metrics, several series per LV for the state sets, are
+	// only keyed by the identity of the LV, like with --collector.info-metrics.
+	attrLabels := []string{"vg", "name", "uuid"}
 	return &lvCollector{
+		scrape: scrape,
+		lvInfoMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "info"),
+			"LVM LV descriptive labels, the other LV metrics being keyed by vg, name and uuid",
+			[]string{"vg", "name", "uuid", "path", "dm_path", "device", "host", "segtype", "pool", "active_status"}, nil,
+		),
+		lvTagInfoMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "tag_info"),
+			"LVM LV tags, one series per tag",
+			[]string{"name", "vg", "tag"}, nil,
+		),
+		lvHostInfoMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "host_info"),
+			"Host which created the LVM LV",
+			[]string{"name", "vg", "host"}, nil,
+		),
+		lvCreationTimestampMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "creation_timestamp_seconds"),
+			"Creation time of the LVM LV in seconds since the epoch",
+			[]string{"name", "vg"}, nil,
+		),
 		lvSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "total_size_bytes"),
 			"LVM LV total size in bytes",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			labels, nil,
 		),
 		lvUsedSizePercentMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "used_percent"),
 			"LVM LV used size in percentage",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			labels, nil,
 		),
-		lvPermissionMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "permission"),
+		lvPermissionMetric: newEnumDesc(prometheus.BuildFQName("lvm", "lv", "permission"),
 			"VG permissions: [-1: undefined], [0: unknown], [1: writeable], [2: read-only], [3: read-only-override]",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			"LV permissions, 1 for the current state",
+			labels,
 		),
-		lvBehaviourWhenFullMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "when_full"),
+		lvBehaviourWhenFullMetric: newEnumDesc(prometheus.BuildFQName("lvm", "lv", "when_full"),
 			"For thin pools, behavior when full: [-1: undefined], [0: error], [1: queue]",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			"For thin pools, behavior when full, 1 for the current state",
+			labels,
 		),
-		lvHealthStatusMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "health_status"),
+		lvHealthStatusMetric: newEnumDesc(prometheus.BuildFQName("lvm", "lv", "health_status"),
 			"LV health status: [-1: undefined], [0: \"\"], [1: partial], [2: refresh needed], [3: mismatches exist]",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			"LV health status, 1 for the current state",
+			labels,
 		),
-		lvRaidSyncActionMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "raid_sync_action"),
+		lvRaidSyncActionMetric: newEnumDesc(prometheus.BuildFQName("lvm", "lv", "raid_sync_action"),
 			"For LV RAID, the current synchronization action being performed: [-1: undefined], [0: idle], [1: frozen], [2: resync], [3: recover], [4: check], [5: repair]",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			"For LV RAID, the current synchronization action being performed, 1 for the current state",
+			labels,
 		),
 		lvMetadataSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "mda_total_size_bytes"),
 			"LVM LV metadata size in bytes",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			labels, nil,
 		),
 		lvMetadataUsedPercentMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "mda_used_percent"),
 			"LVM LV metadata used size in percentage",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			labels, nil,
 		),
 		lvSnapshotUsedPercentMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "snap_percent"),
 			"LVM LV snap used size in percentage",
-			[]string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}, nil,
+			labels, nil,
+		),
+		lvVolumeTypeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "volume_type"),
+			"LVM LV volume type from lv_attr, 1 for the current type",
+			append(attrLabels, "volume_type"), nil,
+		),
+		lvAllocationPolicyMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "allocation_policy"),
+			"LVM LV allocation policy from lv_attr, 1 for the current policy",
+			append(attrLabels, "policy"), nil,
+		),
+		lvAllocationLockedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "allocation_locked"),
+			"Whether the LVM LV allocation policy is locked",
+			attrLabels, nil,
+		),
+		lvFixedMinorMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "fixed_minor"),
+			"Whether the LVM LV has a fixed minor number",
+			attrLabels, nil,
+		),
+		lvStateMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "state"),
+			"LVM LV state from lv_attr, 1 for the current state",
+			append(attrLabels, "state"), nil,
+		),
+		lvOpenMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "open"),
+			"Whether the LVM LV device is open",
+			attrLabels, nil,
+		),
+		lvTargetTypeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "target_type"),
+			"LVM LV device mapper target type from lv_attr, 1 for the current type",
+			append(attrLabels, "target_type"), nil,
+		),
+		lvZeroingMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "zeroing"),
+			"Whether newly-allocated data blocks of the LVM LV are zeroed before use",
+			attrLabels, nil,
+		),
+		lvHealthMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "health"),
+			"LVM LV health from lv_attr, 1 for the current health",
+			append(attrLabels, "health"), nil,
+		),
+		lvSkipActivationMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "skip_activation"),
+			"Whether the LVM LV is skipped on activation",
+			attrLabels, nil,
 		),
 	}
 }
@@ -69,6 +159,12 @@ func NewLvCollector() *lvCollector {
 // It essentially writes all descriptors to the prometheus desc channel.
 func (collector *lvCollector) Describe(ch chan<- *prometheus.Desc) {
 	//Update this section with the each metric you create for a given collector
+	if *infoMetrics {
+		ch <- collector.lvInfoMetric
+	}
+	ch <- collector.lvTagInfoMetric
+	ch <- collector.lvHostInfoMetric
+	ch <- collector.lvCreationTimestampMetric
 	ch <- collector.lvSizeMetric
 	ch <- collector.lvUsedSizePercentMetric
 	ch <- collector.lvPermissionMetric
@@ -78,24 +174,85 @@ func (collector *lvCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.lvMetadataSizeMetric
 	ch <- collector.lvMetadataUsedPercentMetric
 	ch <- collector.lvSnapshotUsedPercentMetric
+	ch <- collector.lvVolumeTypeMetric
+	ch <- collector.lvAllocationPolicyMetric
+	ch <- collector.lvAllocationLockedMetric
+	ch <- collector.lvFixedMinorMetric
+	ch <- collector.lvStateMetric
+	ch <- collector.lvOpenMetric
+	ch <- collector.lvTargetTypeMetric
+	ch <- collector.lvZeroingMetric
+	ch <- collector.lvHealthMetric
+	ch <- collector.lvSkipActivationMetric
 }
 
-// Collect implements required collect function for all prometheus collectors
-func (collector *lvCollector) Collect(ch chan<- prometheus.Metric) {
-	lvList, err := lvm.ListLVMLogicalVolume()
+// Update implements the Collector interface, it fails when the
+// list of lvm logical volumes cannot be fetched.
+func (collector *lvCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
 	if err != nil {
-		klog.Errorf("error in getting the list of lvm logical volumes: %v", err)
-	} else {
-		for _, lv := range lvList {
-			ch <- prometheus.MustNewConstMetric(collector.lvSizeMetric, prometheus.GaugeValue, lv.Size.AsApproximateFloat64(), lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
-			ch <- prometheus.MustNewConstMetric(collector.lvUsedSizePercentMetric, prometheus.GaugeValue, lv.UsedSizePercent, lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
-			ch <- prometheus.MustNewConstMetric(collector.lvPermissionMetric, prometheus.GaugeValue, float64(lv.Permission), lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
-			ch <- prometheus.MustNewConstMetric(collector.lvBehaviourWhenFullMetric, prometheus.GaugeValue, float64(lv.BehaviourWhenFull), lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
-			ch <- prometheus.MustNewConstMetric(collector.lvHealthStatusMetric, prometheus.GaugeValue, float64(lv.HealthStatus), lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
-			ch <- prometheus.MustNewConstMetric(collector.lvRaidSyncActionMetric, prometheus.GaugeValue, float64(lv.RaidSyncAction), lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
-			ch <- prometheus.MustNewConstMetric(collector.lvMetadataSizeMetric, prometheus.GaugeValue, lv.MetadataSize.AsApproximateFloat64(), lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
-			ch <- prometheus.MustNewConstMetric(collector.lvMetadataUsedPercentMetric, prometheus.GaugeValue, lv.MetadataUsedPercent, lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
-			ch <- prometheus.MustNewConstMetric(collector.lvSnapshotUsedPercentMetric, prometheus.GaugeValue, lv.SnapshotUsedPercent, lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
+		return fmt.Errorf("error in getting the list of lvm logical volumes: %w", err)
+	}
+	for _, lv := range report.LogicalVolumes {
+		// Like lvs, only report the logical volumes users deal with.
+		if lv.Hidden {
+			continue
+		}
+		labels := lvLabelValues(lv)
+		if *infoMetrics {
+			ch <- prometheus.MustNewConstMetric(collector.lvInfoMetric, prometheus.GaugeValue, 1, lv.VGName, lv.Name, lv.UUID, lv.Path, lv.DMPath, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus)
+		}
+		for _, tag := range lv.Tags {
+			ch <- prometheus.MustNewConstMetric(collector.lvTagInfoMetric, prometheus.GaugeValue, 1, lv.Name, lv.VGName, tag)
+		}
+		if lv.Host != "" {
+			ch <- prometheus.MustNewConstMetric(collector.lvHostInfoMetric, prometheus.GaugeValue, 1, lv.Name, lv.VGName, lv.Host)
 		}
+		if !lv.CreationTime.IsZero() {
+			ch <- prometheus.MustNewConstMetric(collector.lvCreationTimestampMetric, prometheus.GaugeValue, float64(lv.CreationTime.Unix()), lv.Name, lv.VGName)
+		}
+		ch <- prometheus.MustNewConstMetric(collector.lvSizeMetric, prometheus.GaugeValue, lv.Size.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.lvUsedSizePercentMetric, prometheus.GaugeValue, lv.UsedSizePercent, labels...)
+		ch <- prometheus.MustNewConstMetric(collector.lvMetadataSizeMetric, prometheus.GaugeValue, lv.MetadataSize.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.lvMetadataUsedPercentMetric, prometheus.GaugeValue, lv.MetadataUsedPercent, labels...)
+		ch <- prometheus.MustNewConstMetric(collector.lvSnapshotUsedPercentMetric, prometheus.GaugeValue, lv.SnapshotUsedPercent, labels...)
+		collectEnum(ch, collector.lvPermissionMetric, "lv_permissions", lv.Permission, lv.RawPermission, labels)
+		collectEnum(ch, collector.lvBehaviourWhenFullMetric, "lv_when_full", lv.BehaviourWhenFull, lv.RawBehaviourWhenFull, labels)
+		collectEnum(ch, collector.lvHealthStatusMetric, "lv_health_status", lv.HealthStatus, lv.RawHealthStatus, labels)
+		collectEnum(ch, collector.lvRaidSyncActionMetric, "raid_sync_action", lv.RaidSyncAction, lv.RawRaidSyncAction, labels)
+		attrLabels := []string{lv.VGName, lv.Name, lv.UUID}
+		collectStateSet(ch, collector.lvVolumeTypeMetric, lvm.LVAttrValues("volume_type"), lv.Attr.VolumeType, attrLabels)
+		collectStateSet(ch, collector.lvAllocationPolicyMetric, lvm.LVAttrValues("allocation_policy"), lv.Attr.AllocationPolicy, attrLabels)
+		collectStateSet(ch, collector.lvStateMetric, lvm.LVAttrValues("state"), lv.Attr.State, attrLabels)
+		collectStateSet(ch, collector.lvTargetTypeMetric, lvm.LVAttrValues("target_type"), lv.Attr.TargetType, attrLabels)
+		collectStateSet(ch, collector.lvHealthMetric, lvm.LVAttrValues("health"), lv.Attr.Health, attrLabels)
+		ch <- prometheus.MustNewConstMetric(collector.lvAllocationLockedMetric, prometheus.GaugeValue, boolToFloat64(lv.Attr.AllocationLocked), attrLabels...)
+		ch <- prometheus.MustNewConstMetric(collector.lvFixedMinorMetric, prometheus.GaugeValue, boolToFloat64(lv.Attr.FixedMinor), attrLabels...)
+		ch <- prometheus.MustNewConstMetric(collector.lvOpenMetric, prometheus.GaugeValue, boolToFloat64(lv.Attr.Open), attrLabels...)
+		ch <- prometheus.MustNewConstMetric(collector.lvZeroingMetric, prometheus.GaugeValue, boolToFloat64(lv.Attr.Zeroing), attrLabels...)
+		ch <- prometheus.MustNewConstMetric(collector.lvSkipActivationMetric, prometheus.GaugeValue, boolToFloat64(lv.Attr.SkipActivation), attrLabels...)
+	}
+	return nil
+}
+
+// lvLabelNames returns the labels of the LV metrics, which only identify
+// the volume when the descriptive ones are moved to lvm_lv_info, followed
+// by the promoted tags.
+func lvLabelNames() []string {
+	names := []string{"name", "path", "dm_path", "vg", "device", "host", "segtype", "pool", "active_status"}
+	if *infoMetrics {
+		names = []string{"vg", "name", "uuid"}
+	}
+	names = append(names, tagLabelNames()...)
+	// The descriptors keep the slice, so extending it must not overwrite
+	// the labels of another one.
+	return names[:len(names):len(names)]
+}
+
+// lvLabelValues returns the values of the labels given by lvLabelNames.
+func lvLabelValues(lv lvm.LogicalVolume) []string {
+	if *infoMetrics {
+		return append([]string{lv.VGName, lv.Name, lv.UUID}, tagLabelValues(lv.Tags)...)
 	}
+	return append([]string{lv.Name, lv.Path, lv.DMPath, lv.VGName, lv.Device, lv.Host, lv.SegType, lv.PoolName, lv.ActiveStatus}, tagLabelValues(lv.Tags)...)
 }
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// lvSnapshotCollector exposes the snapshots, thin or not, of the logical
+// volumes, to find the forgotten ones filling the thin pools.
+type lvSnapshotCollector struct {
+	scrape *Scrape
+
+	snapAgeMetric     *prometheus.Desc
+	snapInvalidMetric *prometheus.Desc
+	snapMergingMetric *prometheus.Desc
+	snapCountMetric   *prometheus.Desc
+}
+
+func init() {
+	registerCollector("lv_snapshot", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewLvSnapshotCollector(scrape)
+	})
+}
+
+// NewLvSnapshotCollector initializes every descriptor and returns a pointer to the collector
+func NewLvSnapshotCollector(scrape *Scrape) *lvSnapshotCollector {
+	return &lvSnapshotCollector{
+		scrape: scrape,
+		snapAgeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_snapshot", "age_seconds"),
+			"Time since the LVM snapshot was created in seconds",
+			[]string{"name", "vg", "origin"}, nil,
+		),
+		snapInvalidMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_snapshot", "invalid"),
+			"Whether the LVM snapshot was invalidated, e.g. because it overflowed",
+			[]string{"name", "vg", "origin"}, nil,
+		),
+		snapMergingMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv_snapshot", "merging"),
+			"Whether the LVM snapshot is being merged into its origin",
+			[]string{"name", "vg", "origin"}, nil,
+		),
+		snapCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "snapshot_count"),
+			"Number of snapshots of the LVM LV",
+			[]string{"name", "vg"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *lvSnapshotCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.snapAgeMetric
+	ch <- collector.snapInvalidMetric
+	ch <- collector.snapMergingMetric
+	ch <- collector.snapCountMetric
+}
+
+// Update implements the Collector interface, it fails when the list of
+// lvm logical volumes cannot be fetched.
+func (collector *lvSnapshotCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the list of lvm logical volumes: %w", err)
+	}
+
+	now := time.Now()
+	snapCount := make(map[lvKey]int)
+	for _, lv := range report.LogicalVolumes {
+		if !isSnapshot(lv) {
+			continue
+		}
+		snapCount[lvKey{vg: lv.VGName, name: lv.Origin}]++
+		if !lv.CreationTime.IsZero() {
+			ch <- prometheus.MustNewConstMetric(collector.snapAgeMetric, prometheus.GaugeValue, now.Sub(lv.CreationTime).Seconds(), lv.Name, lv.VGName, lv.Origin)
+		}
+		ch <- prometheus.MustNewConstMetric(collector.snapInvalidMetric, prometheus.GaugeValue, boolToFloat64(lv.SnapshotInvalid), lv.Name, lv.VGName, lv.Origin)
+		ch <- prometheus.MustNewConstMetric(collector.snapMergingMetric, prometheus.GaugeValue, boolToFloat64(lv.Merging), lv.Name, lv.VGName, lv.Origin)
+	}
+	for key, count := range snapCount {
+		ch <- prometheus.MustNewConstMetric(collector.snapCountMetric, prometheus.GaugeValue, float64(count), key.name, key.vg)
+	}
+	return nil
+}
+
+// isSnapshot returns whether lv is a COW snapshot or a thin snapshot. Other
+// LVs have an origin too, e.g. a cached LV has its hidden origin holding
+// the data, and are not snapshots.
+func isSnapshot(lv lvm.LogicalVolume) bool {
+	if lv.Origin == "" || lv.OriginHidden {
+		return false
+	}
+	switch lv.Attr.VolumeType {
+	case "snapshot", "merging snapshot":
+		return true
+	}
+	return lv.SegType == lvm.LVThin
+}
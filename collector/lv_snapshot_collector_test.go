@@ -0,0 +1,31 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"strings"
+	"testing"
+)
+
+func TestLvSnapshotCollector(t *testing.T) {
+	// The thin snapshot snap1 is the only snapshot, cached has a hidden
+	// origin holding its data and is not one.
+	c := testCollector{NewLvSnapshotCollector(newTestScrape())}
+	want := `
+# HELP lvm_lv_snapshot_count Number of snapshots of the LVM LV
+# TYPE lvm_lv_snapshot_count gauge
+lvm_lv_snapshot_count{name="thin1",vg="vg0"} 1
+# HELP lvm_lv_snapshot_invalid Whether the LVM snapshot was invalidated, e.g. because it overflowed
+# TYPE lvm_lv_snapshot_invalid gauge
+lvm_lv_snapshot_invalid{name="snap1",origin="thin1",vg="vg0"} 0
+# HELP lvm_lv_snapshot_merging Whether the LVM snapshot is being merged into its origin
+# TYPE lvm_lv_snapshot_merging gauge
+lvm_lv_snapshot_merging{name="snap1",origin="thin1",vg="vg0"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"lvm_lv_snapshot_count", "lvm_lv_snapshot_invalid", "lvm_lv_snapshot_merging"); err != nil {
+		t.Error(err)
+	}
+	if got := testutil.CollectAndCount(c, "lvm_lv_snapshot_age_seconds"); got != 1 {
+		t.Errorf("got %d snapshot ages, want 1", got)
+	}
+}
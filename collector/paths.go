@@ -0,0 +1,17 @@
+package collector
+
+import (
+	"gopkg.in/alecthomas/kingpin.v2"
+	"path/filepath"
+)
+
+var (
+	// The path of the sysfs mountpoint is configurable so that the
+	// collectors can run in a container or against a fake tree.
+	sysPath = kingpin.Flag("path.sysfs", "sysfs mountpoint.").Default("/sys").String()
+)
+
+// sysFilePath returns the path of name under the sysfs mountpoint.
+func sysFilePath(name ...string) string {
+	return filepath.Join(append([]string{*sysPath}, name...)...)
+}
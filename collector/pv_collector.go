@@ -1,9 +1,8 @@
 package collector
 
 import (
-	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
-	"k8s.io/klog"
 )
 
 // Define a struct for you collector that contains pointers
@@ -11,6 +10,10 @@ import (
 // Note you can also include fields of other types if they provide utility
 // but we just won't be exposing them as metrics.
 type pvCollector struct {
+	scrape *Scrape
+
+	pvInfoMetric *prometheus.Desc
+
 	pvSizeMetric         *prometheus.Desc
 	pvFreeMetric         *prometheus.Desc
 	pvUsedMetric         *prometheus.Desc
@@ -19,33 +22,48 @@ type pvCollector struct {
 	pvMetadataFreeMetric *prometheus.Desc
 }
 
+func init() {
+	registerCollector("pv", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewPvCollector(scrape)
+	})
+}
+
 // You must create a constructor for your collector that
 // initializes every descriptor and returns a pointer to the collector
-func NewPvCollector() *pvCollector {
+func NewPvCollector(scrape *Scrape) *pvCollector {
+	labels := []string{"name", "allocatable", "vg", "missing", "in_use"}
+	if *infoMetrics {
+		labels = []string{"vg", "name", "uuid"}
+	}
 	return &pvCollector{
+		scrape: scrape,
+		pvInfoMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "info"),
+			"LVM PV descriptive labels, the other PV metrics being keyed by vg, name and uuid",
+			[]string{"vg", "name", "uuid", "allocatable", "missing", "in_use"}, nil,
+		),
 		pvSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "total_size_bytes"),
 			"LVM PV total size in bytes",
-			[]string{"name", "allocatable", "vg", "missing", "in_use"}, nil,
+			labels, nil,
 		),
 		pvFreeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "free_size_bytes"),
 			"LVM PV free size in bytes",
-			[]string{"name", "allocatable", "vg", "missing", "in_use"}, nil,
+			labels, nil,
 		),
 		pvUsedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "used_size_bytes"),
 			"LVM PV used size in bytes",
-			[]string{"name", "allocatable", "vg", "missing", "in_use"}, nil,
+			labels, nil,
 		),
 		pvDeviceSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "device_size_bytes"),
 			"LVM PV underlying device size in bytes",
-			[]string{"name", "allocatable", "vg", "missing", "in_use"}, nil,
+			labels, nil,
 		),
 		pvMetadataSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "mda_total_size_bytes"),
 			"LVM PV device smallest metadata area size in bytes",
-			[]string{"name", "allocatable", "vg", "missing", "in_use"}, nil,
+			labels, nil,
 		),
 		pvMetadataFreeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "mda_free_size_bytes"),
 			"LVM PV device free metadata area space in bytes",
-			[]string{"name", "allocatable", "vg", "missing", "in_use"}, nil,
+			labels, nil,
 		),
 	}
 }
@@ -54,6 +72,9 @@ func NewPvCollector() *pvCollector {
 // It essentially writes all descriptors to the prometheus desc channel.
 func (collector *pvCollector) Describe(ch chan<- *prometheus.Desc) {
 	//Update this section with the each metric you create for a given collector
+	if *infoMetrics {
+		ch <- collector.pvInfoMetric
+	}
 	ch <- collector.pvSizeMetric
 	ch <- collector.pvFreeMetric
 	ch <- collector.pvUsedMetric
@@ -62,19 +83,25 @@ func (collector *pvCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.pvMetadataFreeMetric
 }
 
-// Collect implements required collect function for all prometheus collectors
-func (collector *pvCollector) Collect(ch chan<- prometheus.Metric) {
-	pvList, err := lvm.ListLVMPhysicalVolume()
+// Update implements the Collector interface, it fails when the
+// list of lvm physical volumes cannot be fetched.
+func (collector *pvCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
 	if err != nil {
-		klog.Errorf("error in getting the list of lvm physical volumes: %v", err)
-	} else {
-		for _, pv := range pvList {
-			ch <- prometheus.MustNewConstMetric(collector.pvSizeMetric, prometheus.GaugeValue, pv.Size.AsApproximateFloat64(), pv.Name, pv.Allocatable, pv.VGName, pv.Missing, pv.InUse)
-			ch <- prometheus.MustNewConstMetric(collector.pvFreeMetric, prometheus.GaugeValue, pv.Free.AsApproximateFloat64(), pv.Name, pv.Allocatable, pv.VGName, pv.Missing, pv.InUse)
-			ch <- prometheus.MustNewConstMetric(collector.pvUsedMetric, prometheus.GaugeValue, pv.Used.AsApproximateFloat64(), pv.Name, pv.Allocatable, pv.VGName, pv.Missing, pv.InUse)
-			ch <- prometheus.MustNewConstMetric(collector.pvDeviceSizeMetric, prometheus.GaugeValue, pv.DeviceSize.AsApproximateFloat64(), pv.Name, pv.Allocatable, pv.VGName, pv.Missing, pv.InUse)
-			ch <- prometheus.MustNewConstMetric(collector.pvMetadataSizeMetric, prometheus.GaugeValue, pv.MetadataSize.AsApproximateFloat64(), pv.Name, pv.Allocatable, pv.VGName, pv.Missing, pv.InUse)
-			ch <- prometheus.MustNewConstMetric(collector.pvMetadataFreeMetric, prometheus.GaugeValue, pv.MetadataFree.AsApproximateFloat64(), pv.Name, pv.Allocatable, pv.VGName, pv.Missing, pv.InUse)
+		return fmt.Errorf("error in getting the list of lvm physical volumes: %w", err)
+	}
+	for _, pv := range report.PhysicalVolumes {
+		labels := []string{pv.Name, pv.Allocatable, pv.VGName, pv.Missing, pv.InUse}
+		if *infoMetrics {
+			labels = []string{pv.VGName, pv.Name, pv.UUID}
+			ch <- prometheus.MustNewConstMetric(collector.pvInfoMetric, prometheus.GaugeValue, 1, pv.VGName, pv.Name, pv.UUID, pv.Allocatable, pv.Missing, pv.InUse)
 		}
+		ch <- prometheus.MustNewConstMetric(collector.pvSizeMetric, prometheus.GaugeValue, pv.Size.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.pvFreeMetric, prometheus.GaugeValue, pv.Free.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.pvUsedMetric, prometheus.GaugeValue, pv.Used.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.pvDeviceSizeMetric, prometheus.GaugeValue, pv.DeviceSize.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.pvMetadataSizeMetric, prometheus.GaugeValue, pv.MetadataSize.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.pvMetadataFreeMetric, prometheus.GaugeValue, pv.MetadataFree.AsApproximateFloat64(), labels...)
 	}
+	return nil
 }
@@ -0,0 +1,209 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"io/ioutil"
+	"k8s.io/klog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxTopologyDepth bounds the walk down the stacked block devices.
+const maxTopologyDepth = 16
+
+// pvTopologyCollector exposes the block devices backing the physical
+// volumes, found by walking sysfs down from the device of each pv to the
+// disks it is made of.
+type pvTopologyCollector struct {
+	scrape *Scrape
+
+	pvTopologyInfoMetric *prometheus.Desc
+}
+
+func init() {
+	registerCollector("pv_topology", defaultDisabled, func(scrape *Scrape) Collector {
+		return NewPvTopologyCollector(scrape)
+	})
+}
+
+// NewPvTopologyCollector initializes every descriptor and returns a pointer to the collector
+func NewPvTopologyCollector(scrape *Scrape) *pvTopologyCollector {
+	return &pvTopologyCollector{
+		scrape: scrape,
+		pvTopologyInfoMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "topology_info"),
+			"Block devices backing the LVM PV: chain lists the devices level by level from the PV device down to the disks, whose model, serial and wwn are given",
+			[]string{"name", "vg", "device", "type", "chain", "model", "serial", "wwn", "rotational", "logical_sector_size", "physical_sector_size", "discard"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *pvTopologyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.pvTopologyInfoMetric
+}
+
+// Update implements the Collector interface, it fails when the list of
+// lvm physical volumes cannot be fetched. A pv whose device cannot be
+// found in sysfs is logged and skipped.
+func (collector *pvTopologyCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the list of lvm physical volumes: %w", err)
+	}
+	for _, pv := range report.PhysicalVolumes {
+		// Missing physical volumes have no device.
+		if pv.Major <= 0 {
+			continue
+		}
+		link, err := os.Readlink(sysFilePath("dev", "block", fmt.Sprintf("%d:%d", pv.Major, pv.Minor)))
+		if err != nil {
+			klog.Errorf("error in getting the block device of pv %s: %v", pv.Name, err)
+			continue
+		}
+		device := filepath.Base(link)
+
+		levels, disks := blockDeviceChain(device)
+		var chain []string
+		for _, level := range levels {
+			chain = append(chain, strings.Join(level, ","))
+		}
+		var models, serials, wwns []string
+		rotational := "0"
+		for _, disk := range disks {
+			models = append(models, readSysfsAttr(disk, "device/model"))
+			serials = append(serials, readSysfsAttr(disk, "device/serial", "serial"))
+			wwns = append(wwns, readSysfsAttr(disk, "wwid", "device/wwid"))
+			if readSysfsAttr(disk, "queue/rotational") == "1" {
+				rotational = "1"
+			}
+		}
+		// A partition has no request queue of its own, the one of its
+		// disk applies.
+		queue := device
+		if isPartition(device) && len(levels) > 1 {
+			queue = levels[1][0]
+		}
+		discard := "0"
+		if max := readSysfsAttr(queue, "queue/discard_max_bytes"); max != "" && max != "0" {
+			discard = "1"
+		}
+
+		ch <- prometheus.MustNewConstMetric(collector.pvTopologyInfoMetric, prometheus.GaugeValue, 1,
+			pv.Name, pv.VGName, device, blockDeviceType(device), strings.Join(chain, ">"),
+			joinUnique(models), joinUnique(serials), joinUnique(wwns), rotational,
+			readSysfsAttr(queue, "queue/logical_block_size"), readSysfsAttr(queue, "queue/physical_block_size"), discard)
+	}
+	return nil
+}
+
+// blockDeviceType tells what kind of block device a device is: partition,
+// multipath, crypt, lvm, dm, md, loop or disk.
+func blockDeviceType(device string) string {
+	if isPartition(device) {
+		return "partition"
+	}
+	if uuid := readSysfsAttr(device, "dm/uuid"); uuid != "" {
+		// The prefix of the uuid of a device mapper device names the
+		// subsystem which created it.
+		switch {
+		case strings.HasPrefix(uuid, "mpath-"):
+			return "multipath"
+		case strings.HasPrefix(uuid, "CRYPT-"):
+			return "crypt"
+		case strings.HasPrefix(uuid, "LVM-"):
+			return "lvm"
+		case strings.HasPrefix(uuid, "part"):
+			return "partition"
+		}
+		return "dm"
+	}
+	if _, err := os.Stat(sysFilePath("class", "block", device, "md")); err == nil {
+		return "md"
+	}
+	if _, err := os.Stat(sysFilePath("class", "block", device, "loop")); err == nil {
+		return "loop"
+	}
+	return "disk"
+}
+
+// blockDeviceChain walks down from device to the disks it is made of. It
+// returns the devices level by level, starting with device itself, and
+// the disks at the bottom of the chain.
+func blockDeviceChain(device string) ([][]string, []string) {
+	var levels [][]string
+	var disks []string
+	level := []string{device}
+	for depth := 0; len(level) > 0 && depth < maxTopologyDepth; depth++ {
+		levels = append(levels, level)
+		var next []string
+		for _, dev := range level {
+			lower := lowerBlockDevices(dev)
+			if len(lower) == 0 {
+				disks = append(disks, dev)
+			}
+			next = append(next, lower...)
+		}
+		level = next
+	}
+	return levels, disks
+}
+
+// lowerBlockDevices returns the devices a device is directly built on: the
+// disk of a partition, or the slaves of a stacked device like dm or md.
+func lowerBlockDevices(device string) []string {
+	if isPartition(device) {
+		// The directory of a partition lies in the one of its disk.
+		path, err := filepath.EvalSymlinks(sysFilePath("class", "block", device))
+		if err != nil {
+			klog.V(2).Infof("failed to resolve the disk of partition %s: %v", device, err)
+			return nil
+		}
+		return []string{filepath.Base(filepath.Dir(path))}
+	}
+	files, err := ioutil.ReadDir(sysFilePath("class", "block", device, "slaves"))
+	if err != nil {
+		return nil
+	}
+	var slaves []string
+	for _, file := range files {
+		slaves = append(slaves, file.Name())
+	}
+	sort.Strings(slaves)
+	return slaves
+}
+
+// isPartition tells whether device is a partition of a disk, as opposed
+// to e.g. a device mapper device mapping part of a disk.
+func isPartition(device string) bool {
+	_, err := os.Stat(sysFilePath("class", "block", device, "partition"))
+	return err == nil
+}
+
+// readSysfsAttr returns the trimmed content of the first readable of the
+// given attributes of a block device, "" if none is.
+func readSysfsAttr(device string, attrs ...string) string {
+	for _, attr := range attrs {
+		raw, err := ioutil.ReadFile(sysFilePath("class", "block", device, attr))
+		if err == nil {
+			return strings.TrimSpace(string(raw))
+		}
+	}
+	return ""
+}
+
+// joinUnique joins the distinct non-empty values with commas, e.g. the
+// wwn shared by all the paths of a multipath device is reported once.
+func joinUnique(values []string) string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return strings.Join(unique, ",")
+}
@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"strings"
+	"testing"
+)
+
+// useTopologySysfs fakes the sysfs tree of the pvs of the fixtures:
+// /dev/sdb (8:16) is the partition sda1, /dev/sdc (8:32) the multipath
+// device dm-2 over sdb and sdc, /dev/sdd (8:48) the md array md0 over sdd
+// and sde.
+func useTopologySysfs(t *testing.T) func() {
+	return useSysfs(t, map[string]string{
+		"devices/sda/sda1/partition":             "1\n",
+		"devices/sda/device/model":               "ST4000NM0035    \n",
+		"devices/sda/device/serial":              "ZC1A2B3C\n",
+		"devices/sda/wwid":                       "naa.5000c500a1b2c3d4\n",
+		"devices/sda/queue/rotational":           "1\n",
+		"devices/sda/queue/logical_block_size":   "512\n",
+		"devices/sda/queue/physical_block_size":  "4096\n",
+		"devices/sda/queue/discard_max_bytes":    "0\n",
+		"devices/dm-2/dm/uuid":                   "mpath-3600c0ff000d5a1b2\n",
+		"devices/dm-2/queue/logical_block_size":  "512\n",
+		"devices/dm-2/queue/physical_block_size": "4096\n",
+		"devices/dm-2/queue/discard_max_bytes":   "1073741824\n",
+		"devices/sdb/device/model":               "MSA 2040 SAN\n",
+		"devices/sdb/wwid":                       "naa.600c0ff000d5a1b2\n",
+		"devices/sdb/queue/rotational":           "0\n",
+		"devices/sdc/device/model":               "MSA 2040 SAN\n",
+		"devices/sdc/wwid":                       "naa.600c0ff000d5a1b2\n",
+		"devices/sdc/queue/rotational":           "0\n",
+		"devices/md0/md/level":                   "raid1\n",
+		"devices/md0/queue/logical_block_size":   "512\n",
+		"devices/md0/queue/physical_block_size":  "512\n",
+		"devices/md0/queue/discard_max_bytes":    "0\n",
+		"devices/sdd/device/model":               "INTEL SSDSC2KB96\n",
+		"devices/sdd/device/serial":              "PHYS1\n",
+		"devices/sdd/queue/rotational":           "0\n",
+		"devices/sde/device/model":               "INTEL SSDSC2KB96\n",
+		"devices/sde/device/serial":              "PHYS2\n",
+		"devices/sde/queue/rotational":           "0\n",
+	}, map[string]string{
+		"dev/block/8:16":          "../../devices/sda/sda1",
+		"dev/block/8:32":          "../../devices/dm-2",
+		"dev/block/8:48":          "../../devices/md0",
+		"class/block/sda":         "../../devices/sda",
+		"class/block/sda1":        "../../devices/sda/sda1",
+		"class/block/dm-2":        "../../devices/dm-2",
+		"class/block/sdb":         "../../devices/sdb",
+		"class/block/sdc":         "../../devices/sdc",
+		"class/block/md0":         "../../devices/md0",
+		"class/block/sdd":         "../../devices/sdd",
+		"class/block/sde":         "../../devices/sde",
+		"devices/dm-2/slaves/sdc": "../../sdc",
+		"devices/dm-2/slaves/sdb": "../../sdb",
+		"devices/md0/slaves/sdd":  "../../sdd",
+		"devices/md0/slaves/sde":  "../../sde",
+	})
+}
+
+func TestBlockDeviceTopology(t *testing.T) {
+	defer useTopologySysfs(t)()
+
+	tests := []struct {
+		device string
+		typ    string
+		chain  [][]string
+		disks  []string
+	}{
+		{"sda1", "partition", [][]string{{"sda1"}, {"sda"}}, []string{"sda"}},
+		{"dm-2", "multipath", [][]string{{"dm-2"}, {"sdb", "sdc"}}, []string{"sdb", "sdc"}},
+		{"md0", "md", [][]string{{"md0"}, {"sdd", "sde"}}, []string{"sdd", "sde"}},
+		{"sdb", "disk", [][]string{{"sdb"}}, []string{"sdb"}},
+	}
+	for _, test := range tests {
+		t.Run(test.device, func(t *testing.T) {
+			if got := blockDeviceType(test.device); got != test.typ {
+				t.Errorf("got type %s, want %s", got, test.typ)
+			}
+			chain, disks := blockDeviceChain(test.device)
+			if fmt.Sprint(chain) != fmt.Sprint(test.chain) || fmt.Sprint(disks) != fmt.Sprint(test.disks) {
+				t.Errorf("got chain %v down to %v, want %v down to %v", chain, disks, test.chain, test.disks)
+			}
+		})
+	}
+}
+
+func TestPvTopologyCollector(t *testing.T) {
+	defer useTopologySysfs(t)()
+
+	c := testCollector{NewPvTopologyCollector(newTestScrape())}
+	want := `
+# HELP lvm_pv_topology_info Block devices backing the LVM PV: chain lists the devices level by level from the PV device down to the disks, whose model, serial and wwn are given
+# TYPE lvm_pv_topology_info gauge
+lvm_pv_topology_info{chain="sda1>sda",device="sda1",discard="0",logical_sector_size="512",model="ST4000NM0035",name="/dev/sdb",physical_sector_size="4096",rotational="1",serial="ZC1A2B3C",type="partition",vg="vg0",wwn="naa.5000c500a1b2c3d4"} 1
+lvm_pv_topology_info{chain="dm-2>sdb,sdc",device="dm-2",discard="1",logical_sector_size="512",model="MSA 2040 SAN",name="/dev/sdc",physical_sector_size="4096",rotational="0",serial="",type="multipath",vg="vg0",wwn="naa.600c0ff000d5a1b2"} 1
+lvm_pv_topology_info{chain="md0>sdd,sde",device="md0",discard="0",logical_sector_size="512",model="INTEL SSDSC2KB96",name="/dev/sdd",physical_sector_size="512",rotational="0",serial="PHYS1,PHYS2",type="md",vg="",wwn=""} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
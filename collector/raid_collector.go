@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog"
+	"strconv"
+	"strings"
+)
+
+// raidCollector exposes the sync progress and the health of the images
+// of every raid logical volume. The health comes from the raid device
+// mapper target while the LV is active, from the health status of its
+// hidden rimage and rmeta sub-volumes otherwise.
+type raidCollector struct {
+	scrape *Scrape
+
+	raidSyncPercentMetric       *prometheus.Desc
+	raidMismatchCountMetric     *prometheus.Desc
+	raidWriteBehindMetric       *prometheus.Desc
+	raidMinRecoveryRateMetric   *prometheus.Desc
+	raidMaxRecoveryRateMetric   *prometheus.Desc
+	raidImageCountMetric        *prometheus.Desc
+	raidHealthyImageCountMetric *prometheus.Desc
+	raidFailedImageCountMetric  *prometheus.Desc
+}
+
+func init() {
+	registerCollector("raid", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewRaidCollector(scrape)
+	})
+}
+
+// NewRaidCollector initializes every descriptor and returns a pointer to the collector
+func NewRaidCollector(scrape *Scrape) *raidCollector {
+	return &raidCollector{
+		scrape: scrape,
+		raidSyncPercentMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "raid", "sync_percent"),
+			"Percentage of the raid LV which is in sync",
+			[]string{"name", "vg", "segtype"}, nil,
+		),
+		raidMismatchCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "raid", "mismatch_count"),
+			"Number of differences found in the last scrubbing of the raid LV",
+			[]string{"name", "vg", "segtype"}, nil,
+		),
+		raidWriteBehindMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "raid", "write_behind"),
+			"Maximum number of outstanding writes to the write-mostly devices of the raid1 LV",
+			[]string{"name", "vg", "segtype"}, nil,
+		),
+		raidMinRecoveryRateMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "raid", "min_recovery_rate_bytes_per_second"),
+			"Minimum recovery I/O load of the raid LV per device in bytes per second, 0 if unset",
+			[]string{"name", "vg", "segtype"}, nil,
+		),
+		raidMaxRecoveryRateMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "raid", "max_recovery_rate_bytes_per_second"),
+			"Maximum recovery I/O load of the raid LV per device in bytes per second, 0 if unset",
+			[]string{"name", "vg", "segtype"}, nil,
+		),
+		raidImageCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "raid", "image_count"),
+			"Number of images of the raid LV",
+			[]string{"name", "vg", "segtype"}, nil,
+		),
+		raidHealthyImageCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "raid", "healthy_image_count"),
+			"Number of images of the raid LV which are alive and in sync",
+			[]string{"name", "vg", "segtype"}, nil,
+		),
+		raidFailedImageCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "raid", "failed_image_count"),
+			"Number of images of the raid LV which have failed",
+			[]string{"name", "vg", "segtype"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *raidCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.raidSyncPercentMetric
+	ch <- collector.raidMismatchCountMetric
+	ch <- collector.raidWriteBehindMetric
+	ch <- collector.raidMinRecoveryRateMetric
+	ch <- collector.raidMaxRecoveryRateMetric
+	ch <- collector.raidImageCountMetric
+	ch <- collector.raidHealthyImageCountMetric
+	ch <- collector.raidFailedImageCountMetric
+}
+
+// Update implements the Collector interface, it fails when the list of
+// lvm logical volumes cannot be fetched.
+func (collector *raidCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the list of lvm logical volumes: %w", err)
+	}
+
+	var raids []lvm.LogicalVolume
+	for _, lv := range report.LogicalVolumes {
+		if !lv.Hidden && strings.HasPrefix(lv.SegType, lvm.LVRaid) {
+			raids = append(raids, lv)
+		}
+	}
+	if len(raids) == 0 {
+		return nil
+	}
+
+	// Without the device mapper status, the health of every raid falls
+	// back to the one of its sub-volumes.
+	dmStatus, err := collector.scrape.DMStatus()
+	if err != nil {
+		klog.Errorf("error in getting the device mapper status: %v", err)
+	}
+	for _, lv := range raids {
+		labels := []string{lv.Name, lv.VGName, lv.SegType}
+		ch <- prometheus.MustNewConstMetric(collector.raidSyncPercentMetric, prometheus.GaugeValue, lv.SyncPercent, labels...)
+		ch <- prometheus.MustNewConstMetric(collector.raidMismatchCountMetric, prometheus.GaugeValue, float64(lv.RaidMismatchCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.raidWriteBehindMetric, prometheus.GaugeValue, float64(lv.RaidWriteBehind), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.raidMinRecoveryRateMetric, prometheus.GaugeValue, float64(lv.RaidMinRecoveryRate*1024), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.raidMaxRecoveryRateMetric, prometheus.GaugeValue, float64(lv.RaidMaxRecoveryRate*1024), labels...)
+
+		images, healthy, failed := raidImageHealth(lv, report.LogicalVolumes)
+		if status, ok := dmStatus[lvm.DMName(lv.VGName, lv.Name)]; ok {
+			rs, err := lvm.ParseRaidStatus(status)
+			if err != nil {
+				klog.Errorf("error in parsing the status of raid %v/%v: %v", lv.VGName, lv.Name, err)
+			} else {
+				images = rs.Devices
+				healthy = strings.Count(rs.Health, "A")
+				failed = strings.Count(rs.Health, "D")
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(collector.raidImageCountMetric, prometheus.GaugeValue, float64(images), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.raidHealthyImageCountMetric, prometheus.GaugeValue, float64(healthy), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.raidFailedImageCountMetric, prometheus.GaugeValue, float64(failed), labels...)
+	}
+	return nil
+}
+
+// raidImageHealth counts the images of a raid LV from its hidden
+// <lv>_rimage_<n> sub-volumes. An image is failed when either its
+// rimage or its rmeta sub-volume reports a health status.
+func raidImageHealth(raid lvm.LogicalVolume, lvs []lvm.LogicalVolume) (images, healthy, failed int) {
+	imageFailed := make(map[int]bool)
+	for _, lv := range lvs {
+		if !lv.Hidden || lv.VGName != raid.VGName || lv.Parent != raid.Name {
+			continue
+		}
+		var suffix string
+		isImage := false
+		if i := strings.LastIndex(lv.Name, "_rimage_"); i >= 0 {
+			suffix = lv.Name[i+len("_rimage_"):]
+			isImage = true
+		} else if i := strings.LastIndex(lv.Name, "_rmeta_"); i >= 0 {
+			suffix = lv.Name[i+len("_rmeta_"):]
+		} else {
+			continue
+		}
+		index, err := strconv.Atoi(suffix)
+		if err != nil {
+			continue
+		}
+		if _, ok := imageFailed[index]; !ok && isImage {
+			imageFailed[index] = false
+		}
+		// Any health status, even one missing from lvm.Enums like
+		// "failed", means the sub-volume is unhealthy.
+		if lv.RawHealthStatus != "" {
+			imageFailed[index] = true
+		}
+	}
+	for _, f := range imageFailed {
+		images++
+		if f {
+			failed++
+		} else {
+			healthy++
+		}
+	}
+	return images, healthy, failed
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"context"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"sync"
+)
+
+// Scrape gets the lvm snapshot at most once from its source and shares
+// it between all the collectors taking part in a single scrape, so that
+// the VG, LV and PV metrics always describe the same instant.
+type Scrape struct {
+	ctx      context.Context
+	source   Source
+	once     sync.Once
+	snapshot *Snapshot
+	err      error
+}
+
+// NewScrape returns a Scrape which has not got its snapshot yet.
+// The lvm commands of the scrape are killed once ctx expires.
+func NewScrape(ctx context.Context, source Source) *Scrape {
+	return &Scrape{ctx: ctx, source: source}
+}
+
+// Snapshot returns the lvm snapshot of the scrape, getting it from the
+// source on the first call only. A stale snapshot is returned along with
+// an error.
+func (s *Scrape) Snapshot() (*Snapshot, error) {
+	s.once.Do(func() {
+		s.snapshot, s.err = s.source.Snapshot(s.ctx)
+	})
+	return s.snapshot, s.err
+}
+
+// Report returns the lvm report of the scrape's snapshot.
+func (s *Scrape) Report() (*lvm.Report, error) {
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Report, nil
+}
+
+// DMStatus returns the device mapper status of the scrape's snapshot.
+func (s *Scrape) DMStatus() (map[string]lvm.DMStatus, error) {
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.DMStatus(s.ctx)
+}
+
+// AutoextendConfig returns the autoextend settings of lvm, see
+// getAutoextendConfig.
+func (s *Scrape) AutoextendConfig() (*lvm.AutoextendConfig, error) {
+	return getAutoextendConfig(s.ctx)
+}
@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// segmentsCollector exposes how the logical volumes are laid out on the
+// physical volumes, from the lv and pv segments of the report.
+type segmentsCollector struct {
+	scrape *Scrape
+
+	lvSegmentCountMetric       *prometheus.Desc
+	lvPVAllocatedMetric        *prometheus.Desc
+	pvFreeSegmentCountMetric   *prometheus.Desc
+	pvLargestFreeSegmentMetric *prometheus.Desc
+}
+
+func init() {
+	registerCollector("segments", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewSegmentsCollector(scrape)
+	})
+}
+
+// NewSegmentsCollector initializes every descriptor and returns a pointer to the collector
+func NewSegmentsCollector(scrape *Scrape) *segmentsCollector {
+	return &segmentsCollector{
+		scrape: scrape,
+		lvSegmentCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "segment_count"),
+			"Number of segments of the LVM LV",
+			[]string{"name", "vg"}, nil,
+		),
+		lvPVAllocatedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "lv", "pv_allocated_bytes"),
+			"Space allocated to the LVM LV, including its hidden sub-volumes, on the PV in bytes",
+			[]string{"name", "vg", "pv"}, nil,
+		),
+		pvFreeSegmentCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "free_segment_count"),
+			"Number of runs of free extents of the LVM PV",
+			[]string{"name", "vg"}, nil,
+		),
+		pvLargestFreeSegmentMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "pv", "largest_free_segment_bytes"),
+			"Size of the largest run of free extents of the LVM PV in bytes",
+			[]string{"name", "vg"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *segmentsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.lvSegmentCountMetric
+	ch <- collector.lvPVAllocatedMetric
+	ch <- collector.pvFreeSegmentCountMetric
+	ch <- collector.pvLargestFreeSegmentMetric
+}
+
+// lvPVKey identifies the space of a logical volume on a physical volume.
+type lvPVKey struct {
+	vg, lv, pv string
+}
+
+// Update implements the Collector interface, it fails when the lvm report
+// cannot be fetched.
+func (collector *segmentsCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the lvm report: %w", err)
+	}
+
+	extentSizes := make(map[string]float64)
+	for _, vg := range report.VolumeGroups {
+		extentSizes[vg.Name] = vg.ExtentSize.AsApproximateFloat64()
+	}
+	lvs := make(map[lvKey]lvm.LogicalVolume)
+	segmentCounts := make(map[string]int)
+	for _, lv := range report.LogicalVolumes {
+		lvs[lvKey{vg: lv.VGName, name: lv.Name}] = lv
+	}
+	for _, seg := range report.Segments {
+		segmentCounts[seg.LVUUID]++
+	}
+
+	for _, lv := range report.LogicalVolumes {
+		if lv.Hidden {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(collector.lvSegmentCountMetric, prometheus.GaugeValue, float64(segmentCounts[lv.UUID]), lv.Name, lv.VGName)
+	}
+
+	allocated := make(map[lvPVKey]int64)
+	for _, pv := range report.PhysicalVolumes {
+		if pv.VGName == "" {
+			continue
+		}
+		var freeCount, largestFree int64
+		for _, pvseg := range report.PVSegments {
+			if pvseg.PVUUID != pv.UUID {
+				continue
+			}
+			if pvseg.LVName == "" {
+				freeCount++
+				if pvseg.Size > largestFree {
+					largestFree = pvseg.Size
+				}
+				continue
+			}
+			allocated[lvPVKey{vg: pv.VGName, lv: topLevelLV(lvs, pv.VGName, pvseg.LVName), pv: pv.Name}] += pvseg.Size
+		}
+		extentSize := extentSizes[pv.VGName]
+		ch <- prometheus.MustNewConstMetric(collector.pvFreeSegmentCountMetric, prometheus.GaugeValue, float64(freeCount), pv.Name, pv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.pvLargestFreeSegmentMetric, prometheus.GaugeValue, float64(largestFree)*extentSize, pv.Name, pv.VGName)
+	}
+	for key, extents := range allocated {
+		ch <- prometheus.MustNewConstMetric(collector.lvPVAllocatedMetric, prometheus.GaugeValue, float64(extents)*extentSizes[key.vg], key.lv, key.vg, key.pv)
+	}
+	return nil
+}
+
+// topLevelLV returns the logical volume users deal with which the given,
+// possibly hidden, logical volume is part of, e.g. the raid LV of one of
+// its images.
+func topLevelLV(lvs map[lvKey]lvm.LogicalVolume, vg, name string) string {
+	// Bound the walk in case of a loop in the parents.
+	for depth := 0; depth < len(lvs); depth++ {
+		lv, ok := lvs[lvKey{vg: vg, name: name}]
+		if !ok || !lv.Hidden || lv.Parent == "" {
+			break
+		}
+		name = lv.Parent
+	}
+	return name
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"strings"
+	"testing"
+)
+
+func TestSegmentsCollector(t *testing.T) {
+	// The extents of the hidden sub LVs are accounted to the LVs they
+	// make up, the data of the pool spanning both PVs and the cache pool
+	// of cached being accounted to it. The orphan PV has no extents.
+	c := testCollector{NewSegmentsCollector(newTestScrape())}
+	want := `
+# HELP lvm_lv_pv_allocated_bytes Space allocated to the LVM LV, including its hidden sub-volumes, on the PV in bytes
+# TYPE lvm_lv_pv_allocated_bytes gauge
+lvm_lv_pv_allocated_bytes{name="cached",pv="/dev/sdc",vg="vg0"} 1.350565888e+09
+lvm_lv_pv_allocated_bytes{name="pool",pv="/dev/sdb",vg="vg0"} 4.206886912e+09
+lvm_lv_pv_allocated_bytes{name="pool",pv="/dev/sdc",vg="vg0"} 9.2274688e+07
+lvm_lv_pv_allocated_bytes{name="r1",pv="/dev/sdb",vg="vg0"} 1.077936128e+09
+lvm_lv_pv_allocated_bytes{name="r1",pv="/dev/sdc",vg="vg0"} 1.077936128e+09
+# HELP lvm_lv_segment_count Number of segments of the LVM LV
+# TYPE lvm_lv_segment_count gauge
+lvm_lv_segment_count{name="cached",vg="vg0"} 1
+lvm_lv_segment_count{name="pool",vg="vg0"} 1
+lvm_lv_segment_count{name="r1",vg="vg0"} 1
+lvm_lv_segment_count{name="snap1",vg="vg0"} 1
+lvm_lv_segment_count{name="thin1",vg="vg0"} 1
+# HELP lvm_pv_free_segment_count Number of runs of free extents of the LVM PV
+# TYPE lvm_pv_free_segment_count gauge
+lvm_pv_free_segment_count{name="/dev/sdb",vg="vg0"} 1
+lvm_pv_free_segment_count{name="/dev/sdc",vg="vg0"} 2
+# HELP lvm_pv_largest_free_segment_bytes Size of the largest run of free extents of the LVM PV in bytes
+# TYPE lvm_pv_largest_free_segment_bytes gauge
+lvm_pv_largest_free_segment_bytes{name="/dev/sdb",vg="vg0"} 8.388608e+07
+lvm_pv_largest_free_segment_bytes{name="/dev/sdc",vg="vg0"} 2.428502016e+09
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
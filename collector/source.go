@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"k8s.io/klog"
+	"sync"
+	"time"
+)
+
+// Snapshot is the state of lvm on the node at a given time, which is
+// shared by the collectors of a scrape.
+type Snapshot struct {
+	// Report is the output of `lvm fullreport`.
+	Report *lvm.Report
+
+	// The output of `dmsetup status`, see DMStatus.
+	dmStatusMutex sync.Mutex
+	dmStatusTaken bool
+	dmStatus      map[string]lvm.DMStatus
+	dmStatusErr   error
+
+	// Time is when the snapshot was taken.
+	Time time.Time
+}
+
+func takeSnapshot(ctx context.Context) (*Snapshot, error) {
+	begin := time.Now()
+	report, err := lvm.ListLVMReport(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Report: report, Time: begin}, nil
+}
+
+// DMStatus returns the output of `dmsetup status` keyed by device name,
+// running dmsetup on the first call only so that the scrapes of the
+// collectors not reading the device mapper targets do not pay for it.
+// Only the collectors reading them fail when dmsetup does, the snapshot
+// itself is still usable. A failure caused by ctx expiring is not kept,
+// dmsetup being run again for the next caller.
+func (s *Snapshot) DMStatus(ctx context.Context) (map[string]lvm.DMStatus, error) {
+	s.dmStatusMutex.Lock()
+	defer s.dmStatusMutex.Unlock()
+	if s.dmStatusTaken {
+		return s.dmStatus, s.dmStatusErr
+	}
+	dmStatus, err := lvm.ListDMStatus(ctx)
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	s.dmStatus, s.dmStatusErr, s.dmStatusTaken = dmStatus, err, true
+	return s.dmStatus, s.dmStatusErr
+}
+
+// Source provides the lvm snapshots to the scrapes.
+type Source interface {
+	// Snapshot returns the snapshot a scrape should be served from. A
+	// snapshot may be returned along with an error when it is too old.
+	Snapshot(ctx context.Context) (*Snapshot, error)
+}
+
+// LiveSource takes a new snapshot for every scrape. Scrapes arriving
+// while a snapshot is being taken wait for it instead of running the
+// lvm commands in parallel, each of them until its own context expires.
+type LiveSource struct {
+	mutex  sync.Mutex
+	flight *flight
+}
+
+// flight is a snapshot being taken.
+type flight struct {
+	done     chan struct{}
+	snapshot *Snapshot
+	err      error
+}
+
+// NewLiveSource returns a Source taking a snapshot for every scrape.
+func NewLiveSource() *LiveSource {
+	return &LiveSource{}
+}
+
+// Snapshot implements Source.
+func (l *LiveSource) Snapshot(ctx context.Context) (*Snapshot, error) {
+	l.mutex.Lock()
+	f := l.flight
+	if f == nil {
+		f = &flight{done: make(chan struct{})}
+		l.flight = f
+		go l.take(f)
+	}
+	l.mutex.Unlock()
+
+	select {
+	case <-f.done:
+		return f.snapshot, f.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for lvm snapshot: %w", ctx.Err())
+	}
+}
+
+// take takes the snapshot of the flight. It does not run under the
+// context of any of the scrapes waiting for it, which would fail all of
+// them when the first one gives up: the lvm commands are only bounded by
+// the command timeout, see lvm.SetCommandTimeout.
+func (l *LiveSource) take(f *flight) {
+	f.snapshot, f.err = takeSnapshot(context.Background())
+
+	l.mutex.Lock()
+	l.flight = nil
+	l.mutex.Unlock()
+	close(f.done)
+}
+
+// CachedSource takes the snapshots in the background on a fixed interval
+// and serves the scrapes from the latest one, so that the lvm commands
+// run at the same pace whatever the number of scrapers.
+type CachedSource struct {
+	interval time.Duration
+	maxAge   time.Duration
+
+	mutex    sync.RWMutex
+	snapshot *Snapshot
+	err      error
+}
+
+// NewCachedSource returns a Source refreshing the snapshot every interval
+// once started. Snapshots older than maxAge are reported as stale, 0
+// disables the check.
+func NewCachedSource(interval, maxAge time.Duration) *CachedSource {
+	return &CachedSource{
+		interval: interval,
+		maxAge:   maxAge,
+		err:      fmt.Errorf("no lvm snapshot taken yet"),
+	}
+}
+
+// Start takes a first snapshot and keeps refreshing it in the background
+// until ctx is done.
+func (c *CachedSource) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			c.refresh(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (c *CachedSource) refresh(ctx context.Context) {
+	snapshot, err := takeSnapshot(ctx)
+	if err == nil {
+		// The dm status is taken along with the report, so that the
+		// scrapes do not run dmsetup nor share the failure of one of them.
+		if _, err := snapshot.DMStatus(ctx); err != nil {
+			klog.Errorf("error in getting the device mapper status: %v", err)
+		}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err != nil {
+		// Keep serving the previous snapshot until it gets stale.
+		klog.Errorf("error in refreshing the lvm snapshot: %v", err)
+		c.err = err
+		return
+	}
+	c.snapshot = snapshot
+	c.err = nil
+}
+
+// Snapshot implements Source.
+func (c *CachedSource) Snapshot(ctx context.Context) (*Snapshot, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.snapshot == nil {
+		return nil, c.err
+	}
+	if age := time.Since(c.snapshot.Time); c.maxAge > 0 && age > c.maxAge {
+		if c.err != nil {
+			return c.snapshot, fmt.Errorf("lvm snapshot is stale, taken %v ago: %v", age.Round(time.Second), c.err)
+		}
+		return c.snapshot, fmt.Errorf("lvm snapshot is stale, taken %v ago", age.Round(time.Second))
+	}
+	return c.snapshot, nil
+}
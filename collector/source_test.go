@@ -0,0 +1,186 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedRunner replays the fixtures, holding `lvm fullreport` until gate
+// is closed or ctx expires, and counts the runs of every tool.
+type gatedRunner struct {
+	lvm.Runner
+	started chan struct{}
+	gate    chan struct{}
+
+	mutex sync.Mutex
+	runs  map[string]int
+}
+
+func newGatedRunner() *gatedRunner {
+	return &gatedRunner{
+		Runner:  lvm.FixtureRunner{Dir: fixturesDir},
+		started: make(chan struct{}, 100),
+		gate:    make(chan struct{}),
+		runs:    make(map[string]int),
+	}
+}
+
+func (r *gatedRunner) count(name string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.runs[name]
+}
+
+func (r *gatedRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, int, error) {
+	r.mutex.Lock()
+	r.runs[name]++
+	r.mutex.Unlock()
+	if name == lvm.LVM && len(args) > 0 && args[0] == lvm.FullReport {
+		r.started <- struct{}{}
+		select {
+		case <-r.gate:
+		case <-ctx.Done():
+			return nil, nil, -1, ctx.Err()
+		}
+	}
+	return r.Runner.Run(ctx, name, args...)
+}
+
+func TestLiveSourceCoalescesScrapes(t *testing.T) {
+	r := newGatedRunner()
+	lvm.SetRunner(r)
+	defer lvm.SetRunner(lvm.FixtureRunner{Dir: fixturesDir})
+
+	source := NewLiveSource()
+	const scrapes = 5
+	results := make(chan error, scrapes)
+	go func() {
+		_, err := source.Snapshot(context.Background())
+		results <- err
+	}()
+	<-r.started
+	for i := 1; i < scrapes; i++ {
+		go func() {
+			_, err := source.Snapshot(context.Background())
+			results <- err
+		}()
+	}
+	// Let the scrapes join the snapshot being taken.
+	time.Sleep(100 * time.Millisecond)
+	close(r.gate)
+
+	for i := 0; i < scrapes; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("Snapshot: %v", err)
+		}
+	}
+	if got := r.count(lvm.LVM); got != 1 {
+		t.Errorf("got lvm fullreport run %d times, want once", got)
+	}
+}
+
+func TestLiveSourceScrapeGivingUp(t *testing.T) {
+	r := newGatedRunner()
+	lvm.SetRunner(r)
+	defer lvm.SetRunner(lvm.FixtureRunner{Dir: fixturesDir})
+
+	source := NewLiveSource()
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := source.Snapshot(ctx)
+		first <- err
+	}()
+	<-r.started
+	second := make(chan error, 1)
+	go func() {
+		_, err := source.Snapshot(context.Background())
+		second <- err
+	}()
+
+	// The first scrape giving up does not fail the second one, once it
+	// joined the snapshot being taken.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v for the canceled scrape, want %v", err, context.Canceled)
+	}
+	close(r.gate)
+	if err := <-second; err != nil {
+		t.Errorf("Snapshot: %v", err)
+	}
+}
+
+func TestCachedSource(t *testing.T) {
+	source := NewCachedSource(time.Minute, 3*time.Minute)
+	if _, err := source.Snapshot(context.Background()); err == nil {
+		t.Error("got no error before the first snapshot")
+	}
+
+	source.refresh(context.Background())
+	snapshot, err := source.Snapshot(context.Background())
+	if err != nil || snapshot == nil {
+		t.Fatalf("got snapshot %v and error %v, want a snapshot", snapshot, err)
+	}
+
+	// The previous snapshot is served when a refresh fails, until it
+	// gets stale.
+	lvm.SetRunner(lvm.FixtureRunner{Dir: "missing"})
+	source.refresh(context.Background())
+	lvm.SetRunner(lvm.FixtureRunner{Dir: fixturesDir})
+	if got, err := source.Snapshot(context.Background()); got != snapshot || err != nil {
+		t.Errorf("got snapshot %p and error %v, want %p", got, err, snapshot)
+	}
+
+	snapshot.Time = time.Now().Add(-4 * time.Minute)
+	if got, err := source.Snapshot(context.Background()); got != snapshot || err == nil {
+		t.Errorf("got snapshot %p and error %v, want %p reported as stale", got, err, snapshot)
+	}
+}
+
+func TestCachedSourceTakesDMStatus(t *testing.T) {
+	r := newGatedRunner()
+	close(r.gate)
+	lvm.SetRunner(r)
+	defer lvm.SetRunner(lvm.FixtureRunner{Dir: fixturesDir})
+
+	source := NewCachedSource(time.Minute, 3*time.Minute)
+	source.refresh(context.Background())
+	if got := r.count(lvm.DMSetup); got != 1 {
+		t.Errorf("got dmsetup run %d times by the refresh, want once", got)
+	}
+
+	// A scrape giving up before reading the dm status gets it all the same.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	snapshot, err := source.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if dmStatus, err := snapshot.DMStatus(ctx); err != nil || len(dmStatus) == 0 {
+		t.Errorf("got %d devices and error %v, want the dm status of the refresh", len(dmStatus), err)
+	}
+	if got := r.count(lvm.DMSetup); got != 1 {
+		t.Errorf("got dmsetup run %d times, want once", got)
+	}
+}
+
+func TestSnapshotDMStatusNotKeptOnContextError(t *testing.T) {
+	snapshot, err := takeSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("takeSnapshot: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := snapshot.DMStatus(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if dmStatus, err := snapshot.DMStatus(context.Background()); err != nil || len(dmStatus) == 0 {
+		t.Errorf("got %d devices and error %v, want the dm status", len(dmStatus), err)
+	}
+}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+// statsCollector exposes the counters kept by the lvm package about the
+// commands it ran. Unlike the other collectors it lives as long as the
+// exporter, since its counters span all the scrapes.
+type statsCollector struct {
+	commandWarningsMetric  *prometheus.Desc
+	commandExitCodesMetric *prometheus.Desc
+	parseErrorsMetric      *prometheus.Desc
+}
+
+// NewStatsCollector returns a collector of the lvm command counters.
+func NewStatsCollector() *statsCollector {
+	return &statsCollector{
+		commandWarningsMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "command", "warnings_total"),
+			"Number of warnings printed by the lvm commands on stderr, by kind",
+			[]string{"command", "kind"}, nil,
+		),
+		commandExitCodesMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "command", "exit_codes_total"),
+			"Number of runs of the lvm commands by exit code, -1 if the command could not be run or was killed",
+			[]string{"command", "exit_code"}, nil,
+		),
+		parseErrorsMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "", "parse_errors_total"),
+			"Number of lvm report rows skipped because they could not be parsed, by object type",
+			[]string{"object"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (collector *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.commandWarningsMetric
+	ch <- collector.commandExitCodesMetric
+	ch <- collector.parseErrorsMetric
+}
+
+// Collect implements prometheus.Collector.
+func (collector *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	for key, count := range lvm.WarningCounts() {
+		ch <- prometheus.MustNewConstMetric(collector.commandWarningsMetric, prometheus.CounterValue, float64(count), key.Command, key.Kind)
+	}
+	for key, count := range lvm.ExitCodeCounts() {
+		ch <- prometheus.MustNewConstMetric(collector.commandExitCodesMetric, prometheus.CounterValue, float64(count), key.Command, strconv.Itoa(key.ExitCode))
+	}
+	for object, count := range lvm.ParseErrorCounts() {
+		ch <- prometheus.MustNewConstMetric(collector.parseErrorsMetric, prometheus.CounterValue, float64(count), object)
+	}
+}
@@ -0,0 +1,176 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog"
+)
+
+// thinPoolCollector exposes how much every thin pool is provisioned and
+// the status of the thin-pool device mapper target of the active ones,
+// which is more precise than the rounded percentages reported by lvs.
+type thinPoolCollector struct {
+	scrape *Scrape
+
+	tpProvisionedMetric     *prometheus.Desc
+	tpOvercommitRatioMetric *prometheus.Desc
+	tpThinVolumeCountMetric *prometheus.Desc
+
+	tpDataUsedBlocksMetric      *prometheus.Desc
+	tpDataTotalBlocksMetric     *prometheus.Desc
+	tpMetadataUsedBlocksMetric  *prometheus.Desc
+	tpMetadataTotalBlocksMetric *prometheus.Desc
+	tpTransactionIDMetric       *prometheus.Desc
+	tpHeldMetadataRootMetric    *prometheus.Desc
+	tpModeMetric                *prometheus.Desc
+	tpNeedsCheckMetric          *prometheus.Desc
+	tpDiscardPassdownMetric     *prometheus.Desc
+}
+
+func init() {
+	registerCollector("thinpool", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewThinPoolCollector(scrape)
+	})
+}
+
+// NewThinPoolCollector initializes every descriptor and returns a pointer to the collector
+func NewThinPoolCollector(scrape *Scrape) *thinPoolCollector {
+	return &thinPoolCollector{
+		scrape: scrape,
+		tpProvisionedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "provisioned_bytes"),
+			"Sum of the virtual sizes of the thin volumes of the thin pool in bytes",
+			[]string{"name", "vg"}, nil,
+		),
+		tpOvercommitRatioMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "overcommit_ratio"),
+			"Ratio of the provisioned size of the thin pool to its size",
+			[]string{"name", "vg"}, nil,
+		),
+		tpThinVolumeCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "thin_volume_count"),
+			"Number of thin volumes in the thin pool",
+			[]string{"name", "vg"}, nil,
+		),
+		tpDataUsedBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "data_used_blocks"),
+			"Number of data blocks in use in the thin pool",
+			[]string{"name", "vg"}, nil,
+		),
+		tpDataTotalBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "data_total_blocks"),
+			"Total number of data blocks of the thin pool",
+			[]string{"name", "vg"}, nil,
+		),
+		tpMetadataUsedBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "mda_used_blocks"),
+			"Number of metadata blocks in use in the thin pool",
+			[]string{"name", "vg"}, nil,
+		),
+		tpMetadataTotalBlocksMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "mda_total_blocks"),
+			"Total number of metadata blocks of the thin pool",
+			[]string{"name", "vg"}, nil,
+		),
+		tpTransactionIDMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "transaction_id"),
+			"Transaction id of the thin pool metadata",
+			[]string{"name", "vg"}, nil,
+		),
+		tpHeldMetadataRootMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "held_mda_root"),
+			"Location of the held metadata snapshot root of the thin pool: [-1: none]",
+			[]string{"name", "vg"}, nil,
+		),
+		tpModeMetric: newEnumDesc(prometheus.BuildFQName("lvm", "thinpool", "mode"),
+			"Thin pool mode: [-1: undefined], [0: rw], [1: ro], [2: out_of_data_space], [3: fail]",
+			"Thin pool mode, 1 for the current state",
+			[]string{"name", "vg"},
+		),
+		tpNeedsCheckMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "needs_check"),
+			"Whether the thin pool metadata needs to be checked: [0: no], [1: yes]",
+			[]string{"name", "vg"}, nil,
+		),
+		tpDiscardPassdownMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "thinpool", "discard_passdown"),
+			"Whether the thin pool passes discards down to its data device: [0: no], [1: yes]",
+			[]string{"name", "vg"}, nil,
+		),
+	}
+}
+
+// Describe writes all descriptors to the prometheus desc channel.
+func (collector *thinPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.tpProvisionedMetric
+	ch <- collector.tpOvercommitRatioMetric
+	ch <- collector.tpThinVolumeCountMetric
+	ch <- collector.tpDataUsedBlocksMetric
+	ch <- collector.tpDataTotalBlocksMetric
+	ch <- collector.tpMetadataUsedBlocksMetric
+	ch <- collector.tpMetadataTotalBlocksMetric
+	ch <- collector.tpTransactionIDMetric
+	ch <- collector.tpHeldMetadataRootMetric
+	ch <- collector.tpModeMetric
+	ch <- collector.tpNeedsCheckMetric
+	ch <- collector.tpDiscardPassdownMetric
+}
+
+// Update implements the Collector interface, it fails when the list of
+// lvm logical volumes or the device mapper status cannot be fetched.
+func (collector *thinPoolCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
+	if err != nil {
+		return fmt.Errorf("error in getting the list of lvm logical volumes: %w", err)
+	}
+
+	provisioned := make(map[lvKey]float64)
+	thinCount := make(map[lvKey]int)
+	for _, lv := range report.LogicalVolumes {
+		if lv.SegType == lvm.LVThin && lv.PoolName != "" {
+			key := lvKey{vg: lv.VGName, name: lv.PoolName}
+			provisioned[key] += lv.Size.AsApproximateFloat64()
+			thinCount[key]++
+		}
+	}
+
+	var pools []lvm.LogicalVolume
+	for _, lv := range report.LogicalVolumes {
+		if lv.SegType != lvm.LVThinPool {
+			continue
+		}
+		pools = append(pools, lv)
+
+		key := lvKey{vg: lv.VGName, name: lv.Name}
+		var ratio float64
+		if size := lv.Size.AsApproximateFloat64(); size > 0 {
+			ratio = provisioned[key] / size
+		}
+		ch <- prometheus.MustNewConstMetric(collector.tpProvisionedMetric, prometheus.GaugeValue, provisioned[key], lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.tpOvercommitRatioMetric, prometheus.GaugeValue, ratio, lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.tpThinVolumeCountMetric, prometheus.GaugeValue, float64(thinCount[key]), lv.Name, lv.VGName)
+	}
+
+	dmStatus, err := collector.scrape.DMStatus()
+	if err != nil {
+		return fmt.Errorf("error in getting the device mapper status: %w", err)
+	}
+	for _, lv := range pools {
+		// Inactive pools have no device mapper target. A pool without
+		// thin volumes may be activated without its -tpool layer, the
+		// thin-pool target then being the one of the pool device itself.
+		status, ok := dmStatus[lvm.DMName(lv.VGName, lv.Name)+lvm.DMThinPoolSuffix]
+		if !ok {
+			status, ok = dmStatus[lvm.DMName(lv.VGName, lv.Name)]
+			if !ok || status.Target != lvm.LVThinPool {
+				continue
+			}
+		}
+		tp, err := lvm.ParseThinPoolStatus(status)
+		if err != nil {
+			klog.Errorf("error in parsing the status of thin pool %v/%v: %v", lv.VGName, lv.Name, err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(collector.tpDataUsedBlocksMetric, prometheus.GaugeValue, float64(tp.UsedDataBlocks), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.tpDataTotalBlocksMetric, prometheus.GaugeValue, float64(tp.TotalDataBlocks), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.tpMetadataUsedBlocksMetric, prometheus.GaugeValue, float64(tp.UsedMetadataBlocks), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.tpMetadataTotalBlocksMetric, prometheus.GaugeValue, float64(tp.TotalMetadataBlocks), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.tpTransactionIDMetric, prometheus.GaugeValue, float64(tp.TransactionID), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.tpHeldMetadataRootMetric, prometheus.GaugeValue, float64(tp.HeldMetadataRoot), lv.Name, lv.VGName)
+		collectEnum(ch, collector.tpModeMetric, "thin_pool_mode", tp.Mode, tp.RawMode, []string{lv.Name, lv.VGName})
+		ch <- prometheus.MustNewConstMetric(collector.tpNeedsCheckMetric, prometheus.GaugeValue, boolToFloat64(tp.NeedsCheck), lv.Name, lv.VGName)
+		ch <- prometheus.MustNewConstMetric(collector.tpDiscardPassdownMetric, prometheus.GaugeValue, boolToFloat64(tp.DiscardPassdown), lv.Name, lv.VGName)
+	}
+	return nil
+}
@@ -1,9 +1,8 @@
 package collector
 
 import (
-	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
-	"k8s.io/klog"
 )
 
 // Define a struct for you collector that contains pointers
@@ -11,6 +10,10 @@ import (
 // Note you can also include fields of other types if they provide utility
 // but we just won't be exposing them as metrics.
 type vgCollector struct {
+	scrape *Scrape
+
+	vgTagInfoMetric *prometheus.Desc
+
 	vgSizeMetric              *prometheus.Desc
 	vgFreeMetric              *prometheus.Desc
 	vgLvCountMetric           *prometheus.Desc
@@ -25,67 +28,121 @@ type vgCollector struct {
 	vgMetadataSizeMetric      *prometheus.Desc
 	vgPermissionsMetric       *prometheus.Desc
 	vgAllocationPolicyMetric  *prometheus.Desc
+	vgExtentSizeMetric        *prometheus.Desc
+	vgExtentCountMetric       *prometheus.Desc
+	vgFreeExtentCountMetric   *prometheus.Desc
+	vgInfoMetric              *prometheus.Desc
+	vgExportedMetric          *prometheus.Desc
+	vgPartialMetric           *prometheus.Desc
+	vgClusteredMetric         *prometheus.Desc
+	vgSharedMetric            *prometheus.Desc
+}
+
+func init() {
+	registerCollector("vg", defaultEnabled, func(scrape *Scrape) Collector {
+		return NewVgCollector(scrape)
+	})
 }
 
 // You must create a constructor for your collector that
 // initializes every descriptor and returns a pointer to the collector
-func NewVgCollector() *vgCollector {
+func NewVgCollector(scrape *Scrape) *vgCollector {
+	labels := append([]string{"name"}, tagLabelNames()...)
 	return &vgCollector{
+		scrape: scrape,
+		vgTagInfoMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "tag_info"),
+			"LVM VG tags, one series per tag",
+			[]string{"name", "tag"}, nil,
+		),
 		vgFreeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "free_size_bytes"),
 			"LVM VG free size in bytes",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "total_size_bytes"),
 			"LVM VG total size in bytes",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgLvCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "lv_count"),
 			"Number of LVs in VG",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgPvCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "pv_count"),
 			"Number of PVs in VG",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgMaxLvMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "max_lv_count"),
 			"LMaximum number of LVs allowed in VG or 0 if unlimited",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgMaxPvMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "max_pv_count"),
 			"Maximum number of PVs allowed in VG or 0 if unlimited",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgSnapCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "snap_count"),
 			"Number of snapshots in VG",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgMissingPvCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "missing_pv_count"),
 			"Number of PVs in VG which are missing",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgMetadataCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "mda_count"),
 			"Number of metadata areas on this VG",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgMetadataUsedCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "mda_used_count"),
 			"Number of metadata areas in use on this VG",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgMetadataFreeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "mda_free_size_bytes"),
 			"Free metadata area space for this VG in bytes",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
 		vgMetadataSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "mda_total_size_bytes"),
 			"Size of smallest metadata area for this VG in bytes",
-			[]string{"name"}, nil,
+			labels, nil,
 		),
-		vgPermissionsMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "permission"),
+		vgPermissionsMetric: newEnumDesc(prometheus.BuildFQName("lvm", "vg", "permission"),
 			"VG permissions: [-1: undefined], [0: writeable], [1: read-only]",
-			[]string{"name"}, nil,
+			"VG permissions, 1 for the current state",
+			labels,
 		),
-		vgAllocationPolicyMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "allocation_policy"),
+		vgAllocationPolicyMetric: newEnumDesc(prometheus.BuildFQName("lvm", "vg", "allocation_policy"),
 			"VG allocation policy: [-1: undefined], [0: normal], [1: contiguous], [2: cling], [3: anywhere], [4: inherited]",
-			[]string{"name"}, nil,
+			"VG allocation policy, 1 for the current state",
+			labels,
+		),
+		vgExtentSizeMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "extent_size_bytes"),
+			"Size of the physical extents of the VG in bytes",
+			labels, nil,
+		),
+		vgExtentCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "extent_count"),
+			"Total number of physical extents of the VG",
+			labels, nil,
+		),
+		vgFreeExtentCountMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "free_extent_count"),
+			"Number of unallocated physical extents of the VG",
+			labels, nil,
+		),
+		vgInfoMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "info"),
+			"VG system ID and lock type, empty for a VG with no owner or a local VG",
+			[]string{"name", "systemid", "lock_type"}, nil,
+		),
+		vgExportedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "exported"),
+			"Whether the VG is exported",
+			labels, nil,
+		),
+		vgPartialMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "partial"),
+			"Whether PVs of the VG are missing",
+			labels, nil,
+		),
+		vgClusteredMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "clustered"),
+			"Whether the VG is clustered with clvmd",
+			labels, nil,
+		),
+		vgSharedMetric: prometheus.NewDesc(prometheus.BuildFQName("lvm", "vg", "shared"),
+			"Whether the VG is shared with lvmlockd",
+			labels, nil,
 		),
 	}
 }
@@ -94,6 +151,7 @@ func NewVgCollector() *vgCollector {
 // It essentially writes all descriptors to the prometheus desc channel.
 func (collector *vgCollector) Describe(ch chan<- *prometheus.Desc) {
 	//Update this section with the each metric you create for a given collector
+	ch <- collector.vgTagInfoMetric
 	ch <- collector.vgSizeMetric
 	ch <- collector.vgFreeMetric
 	ch <- collector.vgLvCountMetric
@@ -108,29 +166,50 @@ func (collector *vgCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.vgMetadataSizeMetric
 	ch <- collector.vgPermissionsMetric
 	ch <- collector.vgAllocationPolicyMetric
+	ch <- collector.vgExtentSizeMetric
+	ch <- collector.vgExtentCountMetric
+	ch <- collector.vgFreeExtentCountMetric
+	ch <- collector.vgInfoMetric
+	ch <- collector.vgExportedMetric
+	ch <- collector.vgPartialMetric
+	ch <- collector.vgClusteredMetric
+	ch <- collector.vgSharedMetric
 }
 
-// Collect implements required collect function for all prometheus collectors
-func (collector *vgCollector) Collect(ch chan<- prometheus.Metric) {
-	vgList, err := lvm.ListLVMVolumeGroup()
+// Update implements the Collector interface, it fails when the
+// list of lvm volume groups cannot be fetched.
+func (collector *vgCollector) Update(ch chan<- prometheus.Metric) error {
+	report, err := collector.scrape.Report()
 	if err != nil {
-		klog.Errorf("error in getting the list of lvm volume groups: %v", err)
-	} else {
-		for _, vg := range vgList {
-			ch <- prometheus.MustNewConstMetric(collector.vgFreeMetric, prometheus.GaugeValue, vg.Free.AsApproximateFloat64(), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgSizeMetric, prometheus.GaugeValue, vg.Size.AsApproximateFloat64(), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgLvCountMetric, prometheus.GaugeValue, float64(vg.LVCount), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgPvCountMetric, prometheus.GaugeValue, float64(vg.PVCount), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgMaxLvMetric, prometheus.GaugeValue, float64(vg.MaxLV), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgMaxPvMetric, prometheus.GaugeValue, float64(vg.MaxPV), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgSnapCountMetric, prometheus.GaugeValue, float64(vg.SnapCount), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgMissingPvCountMetric, prometheus.GaugeValue, float64(vg.MissingPVCount), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgMetadataCountMetric, prometheus.GaugeValue, float64(vg.MetadataCount), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgMetadataUsedCountMetric, prometheus.GaugeValue, float64(vg.MetadataUsedCount), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgMetadataFreeMetric, prometheus.GaugeValue, vg.MetadataFree.AsApproximateFloat64(), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgMetadataSizeMetric, prometheus.GaugeValue, vg.MetadataSize.AsApproximateFloat64(), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgPermissionsMetric, prometheus.GaugeValue, float64(vg.Permission), vg.Name)
-			ch <- prometheus.MustNewConstMetric(collector.vgAllocationPolicyMetric, prometheus.GaugeValue, float64(vg.AllocationPolicy), vg.Name)
+		return fmt.Errorf("error in getting the list of lvm volume groups: %w", err)
+	}
+	for _, vg := range report.VolumeGroups {
+		labels := append([]string{vg.Name}, tagLabelValues(vg.Tags)...)
+		for _, tag := range vg.Tags {
+			ch <- prometheus.MustNewConstMetric(collector.vgTagInfoMetric, prometheus.GaugeValue, 1, vg.Name, tag)
 		}
+		ch <- prometheus.MustNewConstMetric(collector.vgFreeMetric, prometheus.GaugeValue, vg.Free.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgSizeMetric, prometheus.GaugeValue, vg.Size.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgLvCountMetric, prometheus.GaugeValue, float64(vg.LVCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgPvCountMetric, prometheus.GaugeValue, float64(vg.PVCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgMaxLvMetric, prometheus.GaugeValue, float64(vg.MaxLV), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgMaxPvMetric, prometheus.GaugeValue, float64(vg.MaxPV), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgSnapCountMetric, prometheus.GaugeValue, float64(vg.SnapCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgMissingPvCountMetric, prometheus.GaugeValue, float64(vg.MissingPVCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgMetadataCountMetric, prometheus.GaugeValue, float64(vg.MetadataCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgMetadataUsedCountMetric, prometheus.GaugeValue, float64(vg.MetadataUsedCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgMetadataFreeMetric, prometheus.GaugeValue, vg.MetadataFree.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgMetadataSizeMetric, prometheus.GaugeValue, vg.MetadataSize.AsApproximateFloat64(), labels...)
+		collectEnum(ch, collector.vgPermissionsMetric, "vg_permissions", vg.Permission, vg.RawPermission, labels)
+		collectEnum(ch, collector.vgAllocationPolicyMetric, "vg_allocation_policy", vg.AllocationPolicy, vg.RawAllocationPolicy, labels)
+		ch <- prometheus.MustNewConstMetric(collector.vgExtentSizeMetric, prometheus.GaugeValue, vg.ExtentSize.AsApproximateFloat64(), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgExtentCountMetric, prometheus.GaugeValue, float64(vg.ExtentCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgFreeExtentCountMetric, prometheus.GaugeValue, float64(vg.FreeCount), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgInfoMetric, prometheus.GaugeValue, 1, vg.Name, vg.SystemID, vg.LockType)
+		ch <- prometheus.MustNewConstMetric(collector.vgExportedMetric, prometheus.GaugeValue, boolToFloat64(vg.Exported), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgPartialMetric, prometheus.GaugeValue, boolToFloat64(vg.Partial), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgClusteredMetric, prometheus.GaugeValue, boolToFloat64(vg.Clustered), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.vgSharedMetric, prometheus.GaugeValue, boolToFloat64(vg.Shared), labels...)
 	}
+	return nil
 }
@@ -2,6 +2,7 @@ package lvm
 
 import (
 	"k8s.io/apimachinery/pkg/api/resource"
+	"time"
 )
 
 // VolumeGroup specifies attributes of a given vg exists on node.
@@ -57,6 +58,45 @@ type VolumeGroup struct {
 	// for the volume group
 	MetadataSize resource.Quantity `json:"vg_mda_size"`
 
+	// ExtentSize specifies the size of the physical extents of the
+	// volume group in bytes
+	ExtentSize resource.Quantity `json:"vg_extent_size"`
+
+	// ExtentCount denotes the total number of physical extents of the
+	// volume group.
+	ExtentCount int64 `json:"vg_extent_count"`
+
+	// FreeCount denotes the number of unallocated physical extents of
+	// the volume group.
+	FreeCount int64 `json:"vg_free_count"`
+
+	// SystemID specifies the system ID of the host owning the volume
+	// group, empty if it has none.
+	SystemID string `json:"vg_systemid"`
+
+	// LockType specifies the lock type of a shared volume group
+	// (sanlock/dlm/idm), empty for a local one.
+	LockType string `json:"vg_lock_type"`
+
+	// LockArgs specifies the lock arguments of a shared volume group.
+	LockArgs string `json:"vg_lock_args"`
+
+	// Shared indicates whether the volume group is shared with lvmlockd.
+	Shared bool `json:"vg_shared"`
+
+	// Clustered indicates whether the volume group is clustered with clvmd.
+	Clustered bool `json:"vg_clustered"`
+
+	// Exported indicates whether the volume group is exported.
+	Exported bool `json:"vg_exported"`
+
+	// Partial indicates whether physical volumes of the volume group are
+	// missing.
+	Partial bool `json:"vg_partial"`
+
+	// Tags specifies the tags of the volume group.
+	Tags []string `json:"vg_tags"`
+
 	// Permission indicates the volume group permission
 	// which can be writable or read-only
 	Permission int `json:"vg_permissions"`
@@ -64,6 +104,11 @@ type VolumeGroup struct {
 	// AllocationPolicy indicates the volume group allocation
 	// policy(normal/contiguous/cling/anywhere/inherited)
 	AllocationPolicy int `json:"vg_allocation_policy"`
+
+	// RawPermission and RawAllocationPolicy hold the values lvm reported
+	// for Permission and AllocationPolicy, even those missing from Enums.
+	RawPermission       string
+	RawAllocationPolicy string
 }
 
 // LogicalVolume specifies attributes of a given lv that exists on the node.
@@ -110,6 +155,14 @@ type LogicalVolume struct {
 	// action can be any one of these - (idle/frozen/resync/recover/check/repair)
 	RaidSyncAction int `json:"raid_sync_action"`
 
+	// RawPermission, RawBehaviourWhenFull, RawHealthStatus and
+	// RawRaidSyncAction hold the values lvm reported for the fields above,
+	// even those missing from Enums.
+	RawPermission        string
+	RawBehaviourWhenFull string
+	RawHealthStatus      string
+	RawRaidSyncAction    string
+
 	// ActiveStatus indicates the active state of logical volume
 	ActiveStatus string `json:"lv_active"`
 
@@ -134,6 +187,96 @@ type LogicalVolume struct {
 	// SnapshotUsedPercent specifies the percentage full for snapshots  if
 	// logical volume is active.
 	SnapshotUsedPercent float64 `json:"snap_percent"`
+
+	// CacheTotalBlocks denotes the total cache blocks of a cached logical volume.
+	CacheTotalBlocks int64 `json:"cache_total_blocks"`
+
+	// CacheUsedBlocks denotes the used cache blocks of a cached logical volume.
+	CacheUsedBlocks int64 `json:"cache_used_blocks"`
+
+	// CacheDirtyBlocks denotes the dirty cache blocks of a cached logical volume.
+	CacheDirtyBlocks int64 `json:"cache_dirty_blocks"`
+
+	// CacheReadHits denotes the cache read hits of a cached logical volume.
+	CacheReadHits int64 `json:"cache_read_hits"`
+
+	// CacheReadMisses denotes the cache read misses of a cached logical volume.
+	CacheReadMisses int64 `json:"cache_read_misses"`
+
+	// CacheWriteHits denotes the cache write hits of a cached logical volume.
+	CacheWriteHits int64 `json:"cache_write_hits"`
+
+	// CacheWriteMisses denotes the cache write misses of a cached logical volume.
+	CacheWriteMisses int64 `json:"cache_write_misses"`
+
+	// CacheMode specifies the cache mode of a cached logical volume
+	// (writethrough/writeback/passthrough).
+	CacheMode string `json:"cache_mode"`
+
+	// CachePolicy specifies the cache policy of a cached logical volume.
+	CachePolicy string `json:"cache_policy"`
+
+	// Hidden indicates whether the logical volume is an internal one, like
+	// a raid image or thin pool data volume, which lvs only lists with -a.
+	Hidden bool
+
+	// Attr specifies the decoded lv_attr of the logical volume.
+	Attr LVAttr
+
+	// Tags specifies the tags of the logical volume.
+	Tags []string `json:"lv_tags"`
+
+	// Origin specifies the logical volume a snapshot was taken of, or
+	// the one holding the data of a cached logical volume, empty for the
+	// other logical volumes.
+	Origin string `json:"origin"`
+
+	// OriginHidden indicates whether Origin is a hidden logical volume,
+	// like the origin of a cached logical volume.
+	OriginHidden bool
+
+	// CreationTime specifies when the logical volume was created, zero
+	// when unknown.
+	CreationTime time.Time `json:"lv_time"`
+
+	// SnapshotInvalid indicates whether a snapshot was invalidated, e.g.
+	// because it overflowed.
+	SnapshotInvalid bool `json:"lv_snapshot_invalid"`
+
+	// Merging indicates whether a snapshot is being merged into its origin.
+	Merging bool `json:"lv_merging"`
+
+	// Monitor specifies whether dmeventd monitors the logical volume,
+	// "monitored" or "not monitored", empty when it cannot be monitored.
+	Monitor string `json:"seg_monitor"`
+
+	// KernelMajor and KernelMinor specify the device number of the
+	// active logical volume, -1 when it is not active.
+	KernelMajor int64 `json:"lv_kernel_major"`
+	KernelMinor int64 `json:"lv_kernel_minor"`
+
+	// Parent specifies the logical volume which this one is a sub-volume of.
+	Parent string `json:"lv_parent"`
+
+	// SyncPercent specifies the percentage of a raid or mirror logical
+	// volume which is in sync.
+	SyncPercent float64 `json:"sync_percent"`
+
+	// RaidMismatchCount denotes the number of differences found in the
+	// last scrubbing of a raid logical volume.
+	RaidMismatchCount int64 `json:"raid_mismatch_count"`
+
+	// RaidWriteBehind denotes the maximum number of outstanding writes
+	// to the write-mostly devices of a raid1 logical volume.
+	RaidWriteBehind int64 `json:"raid_write_behind"`
+
+	// RaidMinRecoveryRate specifies the minimum recovery I/O load of a
+	// raid logical volume in kiB/sec/disk.
+	RaidMinRecoveryRate int64 `json:"raid_min_recovery_rate"`
+
+	// RaidMaxRecoveryRate specifies the maximum recovery I/O load of a
+	// raid logical volume in kiB/sec/disk.
+	RaidMaxRecoveryRate int64 `json:"raid_max_recovery_rate"`
 }
 
 // PhysicalVolume specifies attributes of a given pv that exists on the node.
@@ -173,4 +316,85 @@ type PhysicalVolume struct {
 
 	// Name of the volume group which uses this physical volume
 	VGName string `json:"vg_name"`
+
+	// Major and Minor specify the device number of the physical volume,
+	// 0 when unknown.
+	Major int64 `json:"pv_major"`
+	Minor int64 `json:"pv_minor"`
+
+	// Tags specifies the tags of the physical volume.
+	Tags []string `json:"pv_tags"`
+}
+
+// Segment specifies attributes of a given segment of a logical volume.
+type Segment struct {
+	// UUID of the logical volume the segment belongs to.
+	LVUUID string `json:"lv_uuid"`
+
+	// Name of the logical volume the segment belongs to.
+	LVName string `json:"lv_name"`
+
+	// Name of the VG in which the segment is allocated.
+	VGName string `json:"vg_name"`
+
+	// SegType specifies the type of the segment.
+	SegType string `json:"segtype"`
+
+	// Start specifies the offset of the segment within the logical volume in bytes.
+	Start resource.Quantity `json:"seg_start"`
+
+	// Size specifies the size of the segment in bytes.
+	Size resource.Quantity `json:"seg_size"`
+
+	// StartPE specifies the offset of the segment within the logical volume
+	// in physical extents.
+	StartPE int64 `json:"seg_start_pe"`
+
+	// Stripes denotes the number of stripes or mirror/raid legs.
+	Stripes int64 `json:"stripes"`
+
+	// Devices lists the underlying devices used with starting extent numbers.
+	Devices string `json:"devices"`
+
+	// CacheMode specifies the cache mode of a cache segment.
+	CacheMode string `json:"cache_mode"`
+
+	// CachePolicy specifies the cache policy of a cache segment.
+	CachePolicy string `json:"cache_policy"`
+}
+
+// PVSegment specifies attributes of a given segment of a physical volume.
+type PVSegment struct {
+	// Name of the physical volume the segment belongs to.
+	PVName string `json:"pv_name"`
+
+	// UUID of the physical volume the segment belongs to.
+	PVUUID string `json:"pv_uuid"`
+
+	// Name of the volume group which uses this physical volume.
+	VGName string `json:"vg_name"`
+
+	// Start specifies the physical extent number of the start of the segment.
+	Start int64 `json:"pvseg_start"`
+
+	// Size specifies the number of extents in the segment.
+	Size int64 `json:"pvseg_size"`
+
+	// UUID of the logical volume allocated on the segment, empty if the
+	// segment is free.
+	LVUUID string `json:"lv_uuid"`
+
+	// Name of the logical volume allocated on the segment, empty if the
+	// segment is free.
+	LVName string `json:"lv_name"`
+}
+
+// Report specifies a consistent snapshot of all lvm components on the node
+// as returned by a single `lvm fullreport`.
+type Report struct {
+	VolumeGroups    []VolumeGroup
+	LogicalVolumes  []LogicalVolume
+	PhysicalVolumes []PhysicalVolume
+	Segments        []Segment
+	PVSegments      []PVSegment
 }
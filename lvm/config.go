@@ -0,0 +1,86 @@
+package lvm
+
+import (
+	"context"
+	"fmt"
+	"k8s.io/klog"
+	"strconv"
+	"strings"
+)
+
+const (
+	LVMConfig = "lvmconfig"
+)
+
+// AutoextendConfig specifies the effective settings of lvm.conf driving
+// the automatic extension of the thin pools and snapshots by dmeventd.
+type AutoextendConfig struct {
+	// ThinPoolThreshold specifies the data percent of a thin pool above
+	// which it is extended, 100 disabling the extension.
+	ThinPoolThreshold int64 `json:"thin_pool_autoextend_threshold"`
+
+	// ThinPoolPercent specifies by how many percent of its size a thin
+	// pool is extended.
+	ThinPoolPercent int64 `json:"thin_pool_autoextend_percent"`
+
+	// SnapshotThreshold specifies the usage percent of a snapshot above
+	// which it is extended, 100 disabling the extension.
+	SnapshotThreshold int64 `json:"snapshot_autoextend_threshold"`
+
+	// SnapshotPercent specifies by how many percent of its size a
+	// snapshot is extended.
+	SnapshotPercent int64 `json:"snapshot_autoextend_percent"`
+
+	// Monitoring indicates whether the logical volumes are monitored by
+	// dmeventd when activated.
+	Monitoring bool `json:"monitoring"`
+}
+
+// GetAutoextendConfig invokes `lvmconfig` to get the autoextend settings
+// in effect, i.e. lvm.conf merged with the defaults.
+func GetAutoextendConfig(ctx context.Context) (*AutoextendConfig, error) {
+	args := []string{
+		"--typeconfig", "full",
+		"activation/thin_pool_autoextend_threshold",
+		"activation/thin_pool_autoextend_percent",
+		"activation/snapshot_autoextend_threshold",
+		"activation/snapshot_autoextend_percent",
+		"activation/monitoring",
+	}
+	output, err := runCommand(ctx, LVMConfig, args...)
+	if err != nil {
+		klog.Errorf("lvm: error while running command %s %v: %v", LVMConfig, args, err)
+		return nil, err
+	}
+	return decodeAutoextendConfig(output)
+}
+
+func decodeAutoextendConfig(raw []byte) (*AutoextendConfig, error) {
+	// lvmconfig prints one <name>=<value> line per setting.
+	m := make(map[string]string)
+	for _, line := range strings.Split(string(raw), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) == 2 {
+			m[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	config := &AutoextendConfig{}
+	var monitoring int64
+	int64Map := map[string]*int64{
+		"thin_pool_autoextend_threshold": &config.ThinPoolThreshold,
+		"thin_pool_autoextend_percent":   &config.ThinPoolPercent,
+		"snapshot_autoextend_threshold":  &config.SnapshotThreshold,
+		"snapshot_autoextend_percent":    &config.SnapshotPercent,
+		"monitoring":                     &monitoring,
+	}
+	for key, value := range int64Map {
+		count, err := strconv.ParseInt(m[key], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid format of %v=%v in lvm config: %v", key, m[key], err)
+		}
+		*value = count
+	}
+	config.Monitoring = monitoring != 0
+	return config, nil
+}
@@ -0,0 +1,41 @@
+package lvm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetAutoextendConfig(t *testing.T) {
+	defer SetRunner(runner)
+	SetRunner(FixtureRunner{Dir: fixturesDir})
+
+	config, err := GetAutoextendConfig(context.Background())
+	if err != nil {
+		t.Fatalf("GetAutoextendConfig: %v", err)
+	}
+	want := AutoextendConfig{
+		ThinPoolThreshold: 70,
+		ThinPoolPercent:   20,
+		SnapshotThreshold: 100,
+		SnapshotPercent:   20,
+		Monitoring:        true,
+	}
+	if *config != want {
+		t.Errorf("got %+v, want %+v", *config, want)
+	}
+}
+
+func TestDecodeAutoextendConfigInvalid(t *testing.T) {
+	tests := map[string]string{
+		"missing setting": "thin_pool_autoextend_threshold=70\nthin_pool_autoextend_percent=20\n",
+		"invalid value": "thin_pool_autoextend_threshold=seventy\nthin_pool_autoextend_percent=20\n" +
+			"snapshot_autoextend_threshold=100\nsnapshot_autoextend_percent=20\nmonitoring=1\n",
+	}
+	for name, raw := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeAutoextendConfig([]byte(raw)); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
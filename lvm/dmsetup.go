@@ -0,0 +1,291 @@
+package lvm
+
+import (
+	"context"
+	"fmt"
+	"k8s.io/klog"
+	"strconv"
+	"strings"
+)
+
+const (
+	DMSetup = "dmsetup"
+	DMRaid  = "raid"
+
+	// DMThinPoolSuffix is appended to the device mapper name of a thin
+	// pool to get the device running the thin-pool target.
+	DMThinPoolSuffix = "-tpool"
+)
+
+// DMStatus specifies the status of a device mapper target as
+// reported by `dmsetup status`.
+type DMStatus struct {
+	// Name of the device mapper device.
+	Name string
+
+	// Start specifies the start sector of the target.
+	Start int64
+
+	// Length specifies the number of sectors of the target.
+	Length int64
+
+	// Target specifies the type of the target, e.g. thin-pool.
+	Target string
+
+	// Params holds the target specific status fields.
+	Params []string
+}
+
+// ThinPoolStatus specifies the status of a thin-pool target.
+type ThinPoolStatus struct {
+	// TransactionID denotes the transaction id of the pool metadata.
+	TransactionID int64
+
+	// UsedMetadataBlocks denotes the number of metadata blocks in use.
+	UsedMetadataBlocks int64
+
+	// TotalMetadataBlocks denotes the number of metadata blocks of the pool.
+	TotalMetadataBlocks int64
+
+	// UsedDataBlocks denotes the number of data blocks in use.
+	UsedDataBlocks int64
+
+	// TotalDataBlocks denotes the number of data blocks of the pool.
+	TotalDataBlocks int64
+
+	// HeldMetadataRoot denotes the location of the held metadata
+	// snapshot root, -1 if no metadata snapshot is held.
+	HeldMetadataRoot int64
+
+	// Mode indicates the mode of the pool
+	// which can be either one of these- (rw/ro/out_of_data_space/fail)
+	Mode int
+
+	// RawMode holds the mode reported by the target, even if missing from Enums.
+	RawMode string
+
+	// DiscardPassdown indicates whether discards are passed down to
+	// the data device.
+	DiscardPassdown bool
+
+	// NeedsCheck indicates whether the metadata needs to be repaired
+	// by thin_check.
+	NeedsCheck bool
+}
+
+// WritecacheStatus specifies the status of a writecache target.
+type WritecacheStatus struct {
+	// Error indicates whether an I/O error occurred on the cache.
+	Error bool
+
+	// TotalBlocks denotes the number of blocks of the cache.
+	TotalBlocks int64
+
+	// FreeBlocks denotes the number of free blocks of the cache.
+	FreeBlocks int64
+
+	// WritebackBlocks denotes the number of blocks under writeback.
+	WritebackBlocks int64
+}
+
+// RaidStatus specifies the status of a raid target.
+type RaidStatus struct {
+	// RaidType specifies the raid level, e.g. raid1 or raid5_ls.
+	RaidType string
+
+	// Devices denotes the number of raid images.
+	Devices int
+
+	// Health holds one character per image: 'A' alive and in sync,
+	// 'a' alive but not in sync and 'D' dead/failed.
+	Health string
+
+	// SyncedSectors denotes the number of sectors in sync.
+	SyncedSectors int64
+
+	// TotalSectors denotes the number of sectors to be synced.
+	TotalSectors int64
+
+	// SyncAction specifies the current sync action of the raid.
+	SyncAction string
+
+	// MismatchCount denotes the number of mismatches found by the
+	// last check or repair.
+	MismatchCount int64
+}
+
+// ListDMStatus invokes `dmsetup status` to get the status of all the
+// device mapper devices in the node, keyed by device name. Devices made
+// of several targets are reported by their first target.
+func ListDMStatus(ctx context.Context) (map[string]DMStatus, error) {
+	args := []string{"status"}
+	output, err := runCommand(ctx, DMSetup, args...)
+	if err != nil {
+		klog.Errorf("lvm: error while running command %s %v: %v", DMSetup, args, err)
+		return nil, err
+	}
+	return decodeDMStatus(output), nil
+}
+
+func decodeDMStatus(raw []byte) map[string]DMStatus {
+	statuses := make(map[string]DMStatus)
+	for _, line := range strings.Split(string(raw), "\n") {
+		// Only the first target of a device is prefixed with its name.
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+		status := DMStatus{
+			Name:   strings.TrimSuffix(fields[0], ":"),
+			Target: fields[3],
+			Params: fields[4:],
+		}
+		var err error
+		if status.Start, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			klog.Errorf("lvm: skipping dm status %q: %v", line, err)
+			recordParseError("dm_status")
+			continue
+		}
+		if status.Length, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+			klog.Errorf("lvm: skipping dm status %q: %v", line, err)
+			recordParseError("dm_status")
+			continue
+		}
+		statuses[status.Name] = status
+	}
+	return statuses
+}
+
+// DMName returns the device mapper name of a logical volume, in which
+// the dashes of the vg and lv names are doubled.
+//
+// Example: my-vg(vg_name), my_lv(lv_name) -> my--vg-my_lv
+func DMName(vgName, lvName string) string {
+	return strings.Replace(vgName, "-", "--", -1) + "-" + strings.Replace(lvName, "-", "--", -1)
+}
+
+// ParseThinPoolStatus decodes the status of a thin-pool target:
+//
+//	<transaction id> <used metadata blocks>/<total metadata blocks>
+//	<used data blocks>/<total data blocks> <held metadata root>
+//	ro|rw|out_of_data_space [no_]discard_passdown
+//	[error|queue]_if_no_space needs_check|- [metadata_low_watermark]
+func ParseThinPoolStatus(status DMStatus) (ThinPoolStatus, error) {
+	var tp ThinPoolStatus
+	var err error
+	p := status.Params
+
+	if status.Target != LVThinPool {
+		return tp, fmt.Errorf("dm device %v is a %v target, not a %v", status.Name, status.Target, LVThinPool)
+	}
+	if len(p) > 0 && (p[0] == "Fail" || p[0] == "Error") {
+		tp.RawMode = "fail"
+		tp.Mode = getIntFieldValue("thin_pool_mode", tp.RawMode)
+		tp.HeldMetadataRoot = -1
+		return tp, nil
+	}
+	if len(p) < 8 {
+		return tp, fmt.Errorf("invalid thin-pool status %v for dm device %v", p, status.Name)
+	}
+
+	if tp.TransactionID, err = strconv.ParseInt(p[0], 10, 64); err != nil {
+		return tp, fmt.Errorf("invalid transaction id %v for dm device %v: %v", p[0], status.Name, err)
+	}
+	if tp.UsedMetadataBlocks, tp.TotalMetadataBlocks, err = parseBlockRatio(p[1]); err != nil {
+		return tp, fmt.Errorf("invalid metadata blocks %v for dm device %v: %v", p[1], status.Name, err)
+	}
+	if tp.UsedDataBlocks, tp.TotalDataBlocks, err = parseBlockRatio(p[2]); err != nil {
+		return tp, fmt.Errorf("invalid data blocks %v for dm device %v: %v", p[2], status.Name, err)
+	}
+	tp.HeldMetadataRoot = -1
+	if p[3] != "-" {
+		if tp.HeldMetadataRoot, err = strconv.ParseInt(p[3], 10, 64); err != nil {
+			return tp, fmt.Errorf("invalid held metadata root %v for dm device %v: %v", p[3], status.Name, err)
+		}
+	}
+	tp.RawMode = p[4]
+	tp.Mode = getIntFieldValue("thin_pool_mode", tp.RawMode)
+	tp.DiscardPassdown = p[5] == "discard_passdown"
+	tp.NeedsCheck = p[7] == "needs_check"
+
+	return tp, nil
+}
+
+// parseBlockRatio parses a <used>/<total> pair of block counts.
+func parseBlockRatio(s string) (int64, int64, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected <used>/<total>")
+	}
+	used, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	total, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return used, total, nil
+}
+
+// ParseRaidStatus decodes the status of a raid target:
+//
+//	<raid_type> <#devices> <health_chars> <sync_ratio>
+//	<sync_action> <mismatch_cnt> [<data_offset> <journal_char>]
+func ParseRaidStatus(status DMStatus) (RaidStatus, error) {
+	var rs RaidStatus
+	var err error
+	p := status.Params
+
+	if status.Target != DMRaid {
+		return rs, fmt.Errorf("dm device %v is a %v target, not a %v", status.Name, status.Target, DMRaid)
+	}
+	if len(p) < 6 {
+		return rs, fmt.Errorf("invalid raid status %v for dm device %v", p, status.Name)
+	}
+
+	rs.RaidType = p[0]
+	if rs.Devices, err = strconv.Atoi(p[1]); err != nil {
+		return rs, fmt.Errorf("invalid number of devices %v for dm device %v: %v", p[1], status.Name, err)
+	}
+	rs.Health = p[2]
+	if rs.SyncedSectors, rs.TotalSectors, err = parseBlockRatio(p[3]); err != nil {
+		return rs, fmt.Errorf("invalid sync ratio %v for dm device %v: %v", p[3], status.Name, err)
+	}
+	rs.SyncAction = p[4]
+	if rs.MismatchCount, err = strconv.ParseInt(p[5], 10, 64); err != nil {
+		return rs, fmt.Errorf("invalid mismatch count %v for dm device %v: %v", p[5], status.Name, err)
+	}
+
+	return rs, nil
+}
+
+// ParseWritecacheStatus decodes the status of a writecache target:
+//
+//	<error> <total blocks> <free blocks> <blocks under writeback> [...]
+func ParseWritecacheStatus(status DMStatus) (WritecacheStatus, error) {
+	var wc WritecacheStatus
+	p := status.Params
+
+	if status.Target != LVWritecache {
+		return wc, fmt.Errorf("dm device %v is a %v target, not a %v", status.Name, status.Target, LVWritecache)
+	}
+	if len(p) < 4 {
+		return wc, fmt.Errorf("invalid writecache status %v for dm device %v", p, status.Name)
+	}
+
+	values := make([]int64, 4)
+	for i := range values {
+		value, err := strconv.ParseInt(p[i], 10, 64)
+		if err != nil {
+			return wc, fmt.Errorf("invalid writecache status %v for dm device %v: %v", p, status.Name, err)
+		}
+		values[i] = value
+	}
+	wc.Error = values[0] != 0
+	wc.TotalBlocks = values[1]
+	wc.FreeBlocks = values[2]
+	wc.WritebackBlocks = values[3]
+
+	return wc, nil
+}
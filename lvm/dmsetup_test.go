@@ -0,0 +1,216 @@
+package lvm
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeDMStatus(t *testing.T) {
+	raw, err := ioutil.ReadFile(fixturesDir + "/dmsetup_status.stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := decodeDMStatus(raw)
+	if len(statuses) != 10 {
+		t.Fatalf("got %d devices, want 10", len(statuses))
+	}
+	want := DMStatus{
+		Name:   "vg0-thin1",
+		Length: 20971520,
+		Target: "thin",
+		Params: []string{"4194304", "20971519"},
+	}
+	if got := statuses["vg0-thin1"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseThinPoolStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  DMStatus
+		want    ThinPoolStatus
+		wantErr bool
+	}{
+		{
+			name:   "rw",
+			status: dmStatus("thin-pool", "1 10/1024 500/1000 - rw discard_passdown queue_if_no_space - 1024"),
+			want: ThinPoolStatus{
+				TransactionID:       1,
+				UsedMetadataBlocks:  10,
+				TotalMetadataBlocks: 1024,
+				UsedDataBlocks:      500,
+				TotalDataBlocks:     1000,
+				HeldMetadataRoot:    -1,
+				Mode:                0,
+				RawMode:             "rw",
+				DiscardPassdown:     true,
+			},
+		},
+		{
+			name:   "out of data space needing check",
+			status: dmStatus("thin-pool", "7 20/1024 1000/1000 42 out_of_data_space no_discard_passdown error_if_no_space needs_check"),
+			want: ThinPoolStatus{
+				TransactionID:       7,
+				UsedMetadataBlocks:  20,
+				TotalMetadataBlocks: 1024,
+				UsedDataBlocks:      1000,
+				TotalDataBlocks:     1000,
+				HeldMetadataRoot:    42,
+				Mode:                2,
+				RawMode:             "out_of_data_space",
+				NeedsCheck:          true,
+			},
+		},
+		{
+			name:   "failed",
+			status: dmStatus("thin-pool", "Fail"),
+			want:   ThinPoolStatus{HeldMetadataRoot: -1, Mode: 3, RawMode: "fail"},
+		},
+		{
+			name:    "truncated",
+			status:  dmStatus("thin-pool", "1 10/1024 500/1000"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid blocks",
+			status:  dmStatus("thin-pool", "1 10 500/1000 - rw discard_passdown queue_if_no_space -"),
+			wantErr: true,
+		},
+		{
+			name:    "other target",
+			status:  dmStatus("linear", ""),
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseThinPoolStatus(test.status)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRaidStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  DMStatus
+		want    RaidStatus
+		wantErr bool
+	}{
+		{
+			name:   "resyncing",
+			status: dmStatus("raid", "raid1 2 Aa 1048576/2097152 resync 0 0 -"),
+			want: RaidStatus{
+				RaidType:      "raid1",
+				Devices:       2,
+				Health:        "Aa",
+				SyncedSectors: 1048576,
+				TotalSectors:  2097152,
+				SyncAction:    "resync",
+			},
+		},
+		{
+			name:   "failed device with mismatches",
+			status: dmStatus("raid", "raid5_ls 3 ADA 2097152/2097152 idle 128"),
+			want: RaidStatus{
+				RaidType:      "raid5_ls",
+				Devices:       3,
+				Health:        "ADA",
+				SyncedSectors: 2097152,
+				TotalSectors:  2097152,
+				SyncAction:    "idle",
+				MismatchCount: 128,
+			},
+		},
+		{
+			name:    "truncated",
+			status:  dmStatus("raid", "raid1 2 AA"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid devices",
+			status:  dmStatus("raid", "raid1 two AA 1/1 idle 0"),
+			wantErr: true,
+		},
+		{
+			name:    "other target",
+			status:  dmStatus("linear", ""),
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseRaidStatus(test.status)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseWritecacheStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  DMStatus
+		want    WritecacheStatus
+		wantErr bool
+	}{
+		{
+			name:   "healthy",
+			status: dmStatus("writecache", "0 262144 131072 64 0 0 0 0 0 0 0 0 0 0"),
+			want:   WritecacheStatus{TotalBlocks: 262144, FreeBlocks: 131072, WritebackBlocks: 64},
+		},
+		{
+			name:   "error",
+			status: dmStatus("writecache", "-5 262144 0 0"),
+			want:   WritecacheStatus{Error: true, TotalBlocks: 262144},
+		},
+		{
+			name:    "truncated",
+			status:  dmStatus("writecache", "0 262144"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid blocks",
+			status:  dmStatus("writecache", "0 262144 free 0"),
+			wantErr: true,
+		},
+		{
+			name:    "other target",
+			status:  dmStatus("cache", "0 262144 131072 64"),
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseWritecacheStatus(test.status)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// dmStatus returns the status of a device running target with the given
+// space separated parameters.
+func dmStatus(target, params string) DMStatus {
+	status := DMStatus{Name: "vg0-lv", Length: 2097152, Target: target}
+	statuses := decodeDMStatus([]byte("vg0-lv: 0 2097152 " + target + " " + params))
+	if s, ok := statuses["vg0-lv"]; ok {
+		status.Params = s.Params
+	}
+	return status
+}
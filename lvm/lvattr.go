@@ -0,0 +1,132 @@
+package lvm
+
+import (
+	"strings"
+)
+
+// LVAttr specifies the decoded lv_attr bit field of a logical volume. The
+// string fields hold one of the LVAttrValues of the matching position, or
+// "" when lvs reported a character unknown to the exporter.
+type LVAttr struct {
+	// VolumeType specifies the type of the volume, e.g. origin or thin-pool.
+	VolumeType string
+
+	// Permissions specifies whether the volume is writeable or read-only.
+	Permissions string
+
+	// AllocationPolicy specifies the allocation policy of the volume.
+	AllocationPolicy string
+
+	// AllocationLocked indicates whether the allocation policy is locked.
+	AllocationLocked bool
+
+	// FixedMinor indicates whether the volume has a fixed minor number.
+	FixedMinor bool
+
+	// State specifies the state of the volume, e.g. active or suspended.
+	State string
+
+	// Open indicates whether the device of the volume is open.
+	Open bool
+
+	// TargetType specifies the device mapper target type of the volume.
+	TargetType string
+
+	// Zeroing indicates whether newly-allocated data blocks are
+	// overwritten with blocks of zeroes before use.
+	Zeroing bool
+
+	// Health specifies the health of the volume, e.g. partial or failed.
+	Health string
+
+	// SkipActivation indicates whether the volume is skipped on activation.
+	SkipActivation bool
+}
+
+// lvAttrField is a position of lv_attr holding a value, chars[i] standing
+// for values[i] as documented in lvs(8).
+type lvAttrField struct {
+	chars  string
+	values []string
+}
+
+var lvAttrFields = map[string]lvAttrField{
+	"volume_type": {"CmMoOrRsSpviIlcVtTdDe-", []string{
+		"cache", "mirrored", "mirrored without initial sync", "origin",
+		"origin with merging snapshot", "raid", "raid without initial sync",
+		"snapshot", "merging snapshot", "pvmove", "virtual", "image",
+		"image out-of-sync", "mirror log", "under conversion", "thin volume",
+		"thin pool", "thin pool data", "vdo pool", "vdo pool data", "metadata", "none",
+	}},
+	"permissions": {"wrR-", []string{
+		"writeable", "read-only", "read-only activation", "none",
+	}},
+	"allocation_policy": {"acilnACILN", []string{
+		"anywhere", "contiguous", "inherited", "cling", "normal",
+		"anywhere", "contiguous", "inherited", "cling", "normal",
+	}},
+	"state": {"ahsISmMdicCX-", []string{
+		"active", "historical", "suspended", "invalid snapshot",
+		"invalid suspended snapshot", "snapshot merge failed",
+		"suspended snapshot merge failed", "mapped device present without tables",
+		"mapped device present with inactive table", "check needed",
+		"suspended check needed", "unknown", "inactive",
+	}},
+	"target_type": {"Cmrstuv-", []string{
+		"cache", "mirror", "raid", "snapshot", "thin", "unknown", "virtual", "none",
+	}},
+	"health": {"prmwXEDFM-", []string{
+		"partial", "refresh needed", "mismatches exist", "writemostly", "unknown",
+		"error", "out of data space", "failed", "metadata read only", "none",
+	}},
+}
+
+// LVAttrValues returns, in a stable order and without duplicates, the
+// values the given field of LVAttr can take: volume_type, permissions,
+// allocation_policy, state, target_type or health.
+func LVAttrValues(field string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, value := range lvAttrFields[field].values {
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func lvAttrValue(field string, c byte) string {
+	f := lvAttrFields[field]
+	if i := strings.IndexByte(f.chars, c); i >= 0 {
+		return f.values[i]
+	}
+	return ""
+}
+
+// ParseLVAttr decodes the 10 characters of lv_attr:
+//
+//	<volume type> <permissions> <allocation policy> <fixed minor>
+//	<state> <device open> <target type> <zero> <health> <skip activation>
+//
+// Positions missing from attr, as printed by older lvm versions, are
+// decoded as if they were a '-'.
+func ParseLVAttr(attr string) LVAttr {
+	var a LVAttr
+	// Pad to the full length so that every position can be read.
+	attr += strings.Repeat("-", 10)
+
+	a.VolumeType = lvAttrValue("volume_type", attr[0])
+	a.Permissions = lvAttrValue("permissions", attr[1])
+	a.AllocationPolicy = lvAttrValue("allocation_policy", attr[2])
+	a.AllocationLocked = attr[2] >= 'A' && attr[2] <= 'Z'
+	a.FixedMinor = attr[3] == 'm'
+	a.State = lvAttrValue("state", attr[4])
+	a.Open = attr[5] == 'o'
+	a.TargetType = lvAttrValue("target_type", attr[6])
+	a.Zeroing = attr[7] == 'z'
+	a.Health = lvAttrValue("health", attr[8])
+	a.SkipActivation = attr[9] == 'k'
+
+	return a
+}
@@ -0,0 +1,89 @@
+package lvm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLVAttr(t *testing.T) {
+	tests := []struct {
+		attr string
+		want LVAttr
+	}{
+		{
+			attr: "twi-aotz--",
+			want: LVAttr{
+				VolumeType:       "thin pool",
+				Permissions:      "writeable",
+				AllocationPolicy: "inherited",
+				State:            "active",
+				Open:             true,
+				TargetType:       "thin",
+				Zeroing:          true,
+				Health:           "none",
+			},
+		},
+		{
+			attr: "Vri---tz-k",
+			want: LVAttr{
+				VolumeType:       "thin volume",
+				Permissions:      "read-only",
+				AllocationPolicy: "inherited",
+				State:            "inactive",
+				TargetType:       "thin",
+				Zeroing:          true,
+				Health:           "none",
+				SkipActivation:   true,
+			},
+		},
+		{
+			attr: "rwC-aor-p-",
+			want: LVAttr{
+				VolumeType:       "raid",
+				Permissions:      "writeable",
+				AllocationPolicy: "contiguous",
+				AllocationLocked: true,
+				State:            "active",
+				Open:             true,
+				TargetType:       "raid",
+				Health:           "partial",
+			},
+		},
+		{
+			// lvm versions before 2.02.95 print 6 characters only.
+			attr: "-wi-a-",
+			want: LVAttr{
+				VolumeType:       "none",
+				Permissions:      "writeable",
+				AllocationPolicy: "inherited",
+				State:            "active",
+				TargetType:       "none",
+				Health:           "none",
+			},
+		},
+		{
+			attr: "?wi-a-----",
+			want: LVAttr{
+				Permissions:      "writeable",
+				AllocationPolicy: "inherited",
+				State:            "active",
+				TargetType:       "none",
+				Health:           "none",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.attr, func(t *testing.T) {
+			if got := ParseLVAttr(test.attr); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLVAttrValues(t *testing.T) {
+	want := []string{"anywhere", "contiguous", "inherited", "cling", "normal"}
+	if got := LVAttrValues("allocation_policy"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -1,24 +1,30 @@
 package lvm
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	VGList = "vgs"
-	LVList = "lvs"
-	PVList = "pvs"
-
 	PVScan = "pvscan"
 
 	LVThinPool = "thin-pool"
+	LVThin     = "thin"
+
+	LVCache      = "cache"
+	LVWritecache = "writecache"
+
+	// LVRaid is the prefix of the raid segment types, e.g. raid1.
+	LVRaid = "raid"
+
+	// LVTimeLayout is the layout of lv_time, e.g. 2021-07-01 10:00:00 +0000.
+	LVTimeLayout = "2006-01-02 15:04:05 -0700"
 )
 
 var (
@@ -29,57 +35,10 @@ var (
 		"lv_health_status":     {"", "partial", "refresh needed", "mismatches exist"},
 		"vg_allocation_policy": {"normal", "contiguous", "cling", "anywhere", "inherited"},
 		"vg_permissions":       {"writeable", "read-only"},
+		"thin_pool_mode":       {"rw", "ro", "out_of_data_space", "fail"},
 	}
 )
 
-// ListLVMVolumeGroup invokes `vgs` to list all the available volume
-// groups in the node.
-func ListLVMVolumeGroup() ([]VolumeGroup, error) {
-	if err := ReloadLVMMetadataCache(); err != nil {
-		return nil, err
-	}
-
-	args := []string{
-		"--options", "all",
-		"--reportformat", "json",
-		"--units", "b",
-	}
-	cmd := exec.Command(VGList, args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		klog.Errorf("lvm: list volume group cmd %v: %v", args, err)
-		return nil, err
-	}
-	return decodeVgsJSON(output)
-}
-
-func decodeVgsJSON(raw []byte) ([]VolumeGroup, error) {
-	output := &struct {
-		Report []struct {
-			VolumeGroups []map[string]string `json:"vg"`
-		} `json:"report"`
-	}{}
-	var err error
-	if err = json.Unmarshal(raw, output); err != nil {
-		return nil, err
-	}
-
-	if len(output.Report) != 1 {
-		return nil, fmt.Errorf("expected exactly one lvm report")
-	}
-
-	items := output.Report[0].VolumeGroups
-	vgs := make([]VolumeGroup, 0, len(items))
-	for _, item := range items {
-		var vg VolumeGroup
-		if vg, err = parseVolumeGroup(item); err != nil {
-			return vgs, err
-		}
-		vgs = append(vgs, vg)
-	}
-	return vgs, nil
-}
-
 func parseVolumeGroup(m map[string]string) (VolumeGroup, error) {
 	var vg VolumeGroup
 	var count int
@@ -109,10 +68,11 @@ func parseVolumeGroup(m map[string]string) (VolumeGroup, error) {
 	}
 
 	resQuantityMap := map[string]*resource.Quantity{
-		"vg_size":     &vg.Size,
-		"vg_free":     &vg.Free,
-		"vg_mda_size": &vg.MetadataSize,
-		"vg_mda_free": &vg.MetadataFree,
+		"vg_size":        &vg.Size,
+		"vg_free":        &vg.Free,
+		"vg_mda_size":    &vg.MetadataSize,
+		"vg_mda_free":    &vg.MetadataFree,
+		"vg_extent_size": &vg.ExtentSize,
 	}
 
 	for key, value := range resQuantityMap {
@@ -127,12 +87,56 @@ func parseVolumeGroup(m map[string]string) (VolumeGroup, error) {
 		*value = *quantity
 	}
 
+	int64Map := map[string]*int64{
+		"vg_extent_count": &vg.ExtentCount,
+		"vg_free_count":   &vg.FreeCount,
+	}
+	for key, value := range int64Map {
+		number, err := strconv.ParseInt(m[key], 10, 64)
+		if err != nil {
+			err = fmt.Errorf("invalid format of %v=%v for vg %v: %v", key, m[key], vg.Name, err)
+			return vg, err
+		}
+		*value = number
+	}
+
+	vg.Tags = parseTags(m["vg_tags"])
+	vg.SystemID = m["vg_systemid"]
+	vg.LockType = m["vg_lock_type"]
+	vg.LockArgs = m["vg_lock_args"]
+
+	// lvm reports the name of these binary attributes when they are set,
+	// e.g. vg_exported=exported, and an empty string otherwise.
+	vg.Shared = m["vg_shared"] != ""
+	vg.Clustered = m["vg_clustered"] != ""
+	vg.Exported = m["vg_exported"] != ""
+	vg.Partial = m["vg_partial"] != ""
+
 	vg.Permission = getIntFieldValue("vg_permissions", m["vg_permissions"])
 	vg.AllocationPolicy = getIntFieldValue("vg_allocation_policy", m["vg_allocation_policy"])
+	vg.RawPermission = m["vg_permissions"]
+	vg.RawAllocationPolicy = m["vg_allocation_policy"]
 
 	return vg, err
 }
 
+// trimHiddenName strips the brackets lvm lists the hidden logical volumes
+// with, and tells whether there were some.
+func trimHiddenName(name string) (string, bool) {
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		return strings.TrimSuffix(strings.TrimPrefix(name, "["), "]"), true
+	}
+	return name, false
+}
+
+// parseTags splits the comma separated tags of a report field.
+func parseTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
 // This function returns the integer equivalent for different string values for the LVM component(vg,lv) field
 // -1 represents undefined
 func getIntFieldValue(fieldName, fieldValue string) int {
@@ -145,33 +149,13 @@ func getIntFieldValue(fieldName, fieldValue string) int {
 	return mv
 }
 
-func ListLVMLogicalVolume() ([]LogicalVolume, error) {
-	if err := ReloadLVMMetadataCache(); err != nil {
-		return nil, err
-	}
-
-	args := []string{
-		"--options", "lv_all,vg_name,segtype",
-		"--reportformat", "json",
-		"--units", "b",
-	}
-	cmd := exec.Command(LVList, args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		klog.Errorf("lvm: error while running command %s %v: %v", LVList, args, err)
-		return nil, err
-	}
-	return decodeLvsJSON(output)
-}
-
 // ReloadLVMMetadataCache refreshes lvmetad daemon cache used for
 // serving vgs or other lvm utility.
-func ReloadLVMMetadataCache() error {
+func ReloadLVMMetadataCache(ctx context.Context) error {
 	args := []string{"--cache"}
-	cmd := exec.Command(PVScan, args...)
-	output, err := cmd.CombinedOutput()
+	_, err := runCommand(ctx, PVScan, args...)
 	if err != nil {
-		klog.Errorf("lvm: reload lvm metadata cache: %v - %v", string(output), err)
+		klog.Errorf("lvm: reload lvm metadata cache: %v", err)
 		return err
 	}
 	return nil
@@ -183,13 +167,30 @@ func parseLogicalVolume(m map[string]string) (LogicalVolume, error) {
 	var sizeBytes int64
 	var count float64
 
-	lv.Name = m["lv_name"]
+	lv.Name, lv.Hidden = trimHiddenName(m["lv_name"])
 	lv.FullName = m["lv_full_name"]
 	lv.UUID = m["lv_uuid"]
 	lv.Path = m["lv_path"]
 	lv.DMPath = m["lv_dm_path"]
 	lv.VGName = m["vg_name"]
 	lv.ActiveStatus = m["lv_active"]
+	lv.Attr = ParseLVAttr(m["lv_attr"])
+	lv.Tags = parseTags(m["lv_tags"])
+	lv.Origin, lv.OriginHidden = trimHiddenName(m["origin"])
+	lv.SnapshotInvalid = m["lv_snapshot_invalid"] != ""
+	lv.Merging = m["lv_merging"] != ""
+	lv.Monitor = m["seg_monitor"]
+	// The format of lv_time can be changed in lvm.conf, in which case the
+	// creation time is left unknown rather than losing the whole lv.
+	if m["lv_time"] != "" {
+		creationTime, err := time.Parse(LVTimeLayout, m["lv_time"])
+		if err != nil {
+			klog.Errorf("lvm: invalid format of lv_time=%v for lv %v: %v", m["lv_time"], lv.Name, err)
+			recordParseError("lv_time")
+		} else {
+			lv.CreationTime = creationTime
+		}
+	}
 
 	resQuantityMap := map[string]*resource.Quantity{
 		"lv_size":          &lv.Size,
@@ -217,13 +218,19 @@ func parseLogicalVolume(m map[string]string) (LogicalVolume, error) {
 	lv.BehaviourWhenFull = getIntFieldValue("lv_when_full", m["lv_when_full"])
 	lv.HealthStatus = getIntFieldValue("lv_health_status", m["lv_health_status"])
 	lv.RaidSyncAction = getIntFieldValue("raid_sync_action", m["raid_sync_action"])
+	lv.RawPermission = m["lv_permissions"]
+	lv.RawBehaviourWhenFull = m["lv_when_full"]
+	lv.RawHealthStatus = m["lv_health_status"]
+	lv.RawRaidSyncAction = m["raid_sync_action"]
 	lv.Host = m["lv_host"]
 	lv.PoolName = m["pool_lv"]
+	lv.Parent = m["lv_parent"]
 
 	float64Map := map[string]*float64{
 		"data_percent":     &lv.UsedSizePercent,
 		"metadata_percent": &lv.MetadataUsedPercent,
 		"snap_percent":     &lv.SnapshotUsedPercent,
+		"sync_percent":     &lv.SyncPercent,
 	}
 	for key, value := range float64Map {
 		if m[key] == "" {
@@ -238,81 +245,61 @@ func parseLogicalVolume(m map[string]string) (LogicalVolume, error) {
 		*value = count
 	}
 
-	return lv, err
-}
-
-func decodeLvsJSON(raw []byte) ([]LogicalVolume, error) {
-	output := &struct {
-		Report []struct {
-			LogicalVolumes []map[string]string `json:"lv"`
-		} `json:"report"`
-	}{}
-	var err error
-	if err = json.Unmarshal(raw, output); err != nil {
-		return nil, err
-	}
-
-	if len(output.Report) != 1 {
-		return nil, fmt.Errorf("expected exactly one lvm report")
-	}
-
-	items := output.Report[0].LogicalVolumes
-	lvs := make([]LogicalVolume, 0, len(items))
-	for _, item := range items {
-		var lv LogicalVolume
-		if lv, err = parseLogicalVolume(item); err != nil {
-			return lvs, err
-		}
-		deviceName, err := getLvDeviceName(lv.Path)
-		if err != nil {
-			klog.Error(err)
-			return nil, err
+	lv.CacheMode = m["cache_mode"]
+	lv.CachePolicy = m["cache_policy"]
+
+	int64Map := map[string]*int64{
+		"cache_total_blocks":     &lv.CacheTotalBlocks,
+		"cache_used_blocks":      &lv.CacheUsedBlocks,
+		"cache_dirty_blocks":     &lv.CacheDirtyBlocks,
+		"cache_read_hits":        &lv.CacheReadHits,
+		"cache_read_misses":      &lv.CacheReadMisses,
+		"cache_write_hits":       &lv.CacheWriteHits,
+		"cache_write_misses":     &lv.CacheWriteMisses,
+		"raid_mismatch_count":    &lv.RaidMismatchCount,
+		"raid_write_behind":      &lv.RaidWriteBehind,
+		"raid_min_recovery_rate": &lv.RaidMinRecoveryRate,
+		"raid_max_recovery_rate": &lv.RaidMaxRecoveryRate,
+		"lv_kernel_major":        &lv.KernelMajor,
+		"lv_kernel_minor":        &lv.KernelMinor,
+	}
+	for key, value := range int64Map {
+		var number int64
+		if m[key] != "" {
+			number, err = strconv.ParseInt(m[key], 10, 64)
+			if err != nil {
+				err = fmt.Errorf("invalid format of %v=%v for lv %v: %v", key, m[key], lv.Name, err)
+				return lv, err
+			}
 		}
-		lv.Device = deviceName
-		lvs = append(lvs, lv)
+		*value = number
 	}
-	return lvs, nil
+
+	return lv, err
 }
 
 // Function to get LVM Logical volume device
 // It returns LVM logical volume device(dm-*).
 // This is used as a label in metrics which helps us to map lv_name to device.
+// Inactive and hidden logical volumes have no device, for which an empty
+// name is returned.
 //
 // Example: my_lv(lv_name) -> dm-0(device)
-func getLvDeviceName(path string) (string, error) {
+func getLvDeviceName(path string) string {
+	if path == "" {
+		return ""
+	}
 	dmPath, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		klog.Errorf("failed to resolve device mapper from lv path %v: %v", path, err)
-		return "", err
+		klog.V(2).Infof("failed to resolve device mapper from lv path %v: %v", path, err)
+		return ""
 	}
 	deviceName := strings.Split(dmPath, "/")
-	return deviceName[len(deviceName)-1], nil
-}
-
-/*
-ListLVMPhysicalVolume invokes `pvs` to list all the available LVM physical volumes in the node.
-*/
-func ListLVMPhysicalVolume() ([]PhysicalVolume, error) {
-	if err := ReloadLVMMetadataCache(); err != nil {
-		return nil, err
-	}
-
-	args := []string{
-		"--options", "pv_all,vg_name",
-		"--reportformat", "json",
-		"--units", "b",
-	}
-	cmd := exec.Command(PVList, args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		klog.Errorf("lvm: error while running command %s %v: %v", PVList, args, err)
-		return nil, err
-	}
-	return decodePvsJSON(output)
+	return deviceName[len(deviceName)-1]
 }
 
 /*
-To parse the output of pvs command and store it in PhysicalVolume
+To parse a row of the pv report and store it in PhysicalVolume
 */
 func parsePhysicalVolume(m map[string]string) (PhysicalVolume, error) {
 	var pv PhysicalVolume
@@ -325,6 +312,7 @@ func parsePhysicalVolume(m map[string]string) (PhysicalVolume, error) {
 	pv.Allocatable = m["pv_allocatable"]
 	pv.Missing = m["pv_missing"]
 	pv.VGName = m["vg_name"]
+	pv.Tags = parseTags(m["pv_tags"])
 
 	resQuantityMap := map[string]*resource.Quantity{
 		"pv_size":     &pv.Size,
@@ -347,35 +335,21 @@ func parsePhysicalVolume(m map[string]string) (PhysicalVolume, error) {
 		*value = *quantity
 	}
 
-	return pv, err
-}
-
-/*
-Decode json format and store physical volumes in map[string]string
-*/
-func decodePvsJSON(raw []byte) ([]PhysicalVolume, error) {
-	output := &struct {
-		Report []struct {
-			PhysicalVolume []map[string]string `json:"pv"`
-		} `json:"report"`
-	}{}
-	var err error
-	if err = json.Unmarshal(raw, output); err != nil {
-		return nil, err
-	}
-
-	if len(output.Report) != 1 {
-		return nil, fmt.Errorf("expected exactly one lvm report")
+	int64Map := map[string]*int64{
+		"pv_major": &pv.Major,
+		"pv_minor": &pv.Minor,
 	}
-
-	items := output.Report[0].PhysicalVolume
-	pvs := make([]PhysicalVolume, 0, len(items))
-	for _, item := range items {
-		var pv PhysicalVolume
-		if pv, err = parsePhysicalVolume(item); err != nil {
-			return pvs, err
+	for key, value := range int64Map {
+		var number int64
+		if m[key] != "" {
+			number, err = strconv.ParseInt(m[key], 10, 64)
+			if err != nil {
+				err = fmt.Errorf("invalid format of %v=%v for pv %v: %v", key, m[key], pv.Name, err)
+				return pv, err
+			}
 		}
-		pvs = append(pvs, pv)
+		*value = number
 	}
-	return pvs, nil
+
+	return pv, err
 }
@@ -0,0 +1,221 @@
+package lvm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog"
+	"strconv"
+	"strings"
+)
+
+const (
+	LVM        = "lvm"
+	FullReport = "fullreport"
+)
+
+// lvSegmentFields are the segment fields parsed into LogicalVolume.
+var lvSegmentFields = []string{"segtype", "cache_mode", "cache_policy", "seg_monitor"}
+
+// ListLVMReport invokes `lvm fullreport` to take a single snapshot of all
+// the volume groups, logical volumes, physical volumes, LV segments and
+// PV segments in the node. All the sections are produced by the same
+// command under the same lock, so they are consistent with each other.
+// The hidden logical volumes are part of the report, see LogicalVolume.Hidden.
+func ListLVMReport(ctx context.Context) (*Report, error) {
+	if err := ReloadLVMMetadataCache(ctx); err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		FullReport,
+		"--all",
+		"--reportformat", "json",
+		"--units", "b",
+		"--configreport", "vg", "--options", "vg_all",
+		"--configreport", "pv", "--options", "pv_all,vg_name",
+		"--configreport", "lv", "--options", "lv_all,vg_name",
+		"--configreport", "seg", "--options", "seg_all,lv_uuid,lv_name,vg_name",
+		"--configreport", "pvseg", "--options", "pvseg_all,pv_uuid,pv_name,lv_uuid,lv_name,vg_name",
+	}
+	output, err := runCommand(ctx, LVM, args...)
+	if err != nil {
+		klog.Errorf("lvm: error while running command %s %v: %v", LVM, args, err)
+		return nil, err
+	}
+	return decodeFullReportJSON(output)
+}
+
+func decodeFullReportJSON(raw []byte) (*Report, error) {
+	output := &struct {
+		Report []struct {
+			VolumeGroups    []map[string]string `json:"vg"`
+			PhysicalVolumes []map[string]string `json:"pv"`
+			LogicalVolumes  []map[string]string `json:"lv"`
+			Segments        []map[string]string `json:"seg"`
+			PVSegments      []map[string]string `json:"pvseg"`
+		} `json:"report"`
+	}{}
+	if err := json.Unmarshal(raw, output); err != nil {
+		return nil, err
+	}
+
+	// fullreport emits one report per volume group, plus one more for
+	// the orphan physical volumes which do not belong to any group.
+	report := &Report{}
+	for _, r := range output.Report {
+		vgName := ""
+		for _, item := range r.VolumeGroups {
+			vgName = item["vg_name"]
+			vg, err := parseVolumeGroup(item)
+			if err != nil {
+				klog.Errorf("lvm: skipping vg: %v", err)
+				recordParseError("vg")
+				continue
+			}
+			report.VolumeGroups = append(report.VolumeGroups, vg)
+		}
+
+		// The lv report carries no segment fields, so the segment
+		// fields of a logical volume are taken from its first segment.
+		firstSegments := make(map[string]map[string]string)
+		for _, item := range r.Segments {
+			seg, err := parseSegment(item)
+			if err != nil {
+				klog.Errorf("lvm: skipping seg: %v", err)
+				recordParseError("seg")
+				continue
+			}
+			if seg.VGName == "" {
+				seg.VGName = vgName
+			}
+			if _, ok := firstSegments[seg.LVUUID]; !ok {
+				firstSegments[seg.LVUUID] = item
+			}
+			report.Segments = append(report.Segments, seg)
+		}
+
+		for _, item := range r.LogicalVolumes {
+			for _, key := range lvSegmentFields {
+				if _, ok := item[key]; !ok {
+					item[key] = firstSegments[item["lv_uuid"]][key]
+				}
+			}
+			lv, err := parseLogicalVolume(item)
+			if err != nil {
+				klog.Errorf("lvm: skipping lv: %v", err)
+				recordParseError("lv")
+				continue
+			}
+			if lv.VGName == "" {
+				lv.VGName = vgName
+			}
+			lv.Device = getLvDeviceName(lv.Path)
+			report.LogicalVolumes = append(report.LogicalVolumes, lv)
+		}
+
+		for _, item := range r.PhysicalVolumes {
+			pv, err := parsePhysicalVolume(item)
+			if err != nil {
+				klog.Errorf("lvm: skipping pv: %v", err)
+				recordParseError("pv")
+				continue
+			}
+			report.PhysicalVolumes = append(report.PhysicalVolumes, pv)
+		}
+
+		for _, item := range r.PVSegments {
+			pvseg, err := parsePVSegment(item)
+			if err != nil {
+				klog.Errorf("lvm: skipping pvseg: %v", err)
+				recordParseError("pvseg")
+				continue
+			}
+			if pvseg.VGName == "" {
+				pvseg.VGName = vgName
+			}
+			report.PVSegments = append(report.PVSegments, pvseg)
+		}
+	}
+	return report, nil
+}
+
+/*
+To parse a row of the seg report and store it in Segment
+*/
+func parseSegment(m map[string]string) (Segment, error) {
+	var seg Segment
+	var err error
+	var sizeBytes int64
+	var count int64
+
+	seg.LVUUID = m["lv_uuid"]
+	seg.LVName, _ = trimHiddenName(m["lv_name"])
+	seg.VGName = m["vg_name"]
+	seg.SegType = m["segtype"]
+	seg.Devices = m["devices"]
+	seg.CacheMode = m["cache_mode"]
+	seg.CachePolicy = m["cache_policy"]
+
+	resQuantityMap := map[string]*resource.Quantity{
+		"seg_start": &seg.Start,
+		"seg_size":  &seg.Size,
+	}
+	for key, value := range resQuantityMap {
+		sizeBytes, err = strconv.ParseInt(
+			strings.TrimSuffix(strings.ToLower(m[key]), "b"),
+			10, 64)
+		if err != nil {
+			err = fmt.Errorf("invalid format of %v=%v for segment of lv %v: %v", key, m[key], seg.LVName, err)
+			return seg, err
+		}
+		quantity := resource.NewQuantity(sizeBytes, resource.BinarySI)
+		*value = *quantity
+	}
+
+	int64Map := map[string]*int64{
+		"seg_start_pe": &seg.StartPE,
+		"stripes":      &seg.Stripes,
+	}
+	for key, value := range int64Map {
+		count, err = strconv.ParseInt(m[key], 10, 64)
+		if err != nil {
+			err = fmt.Errorf("invalid format of %v=%v for segment of lv %v: %v", key, m[key], seg.LVName, err)
+			return seg, err
+		}
+		*value = count
+	}
+
+	return seg, err
+}
+
+/*
+To parse a row of the pvseg report and store it in PVSegment
+*/
+func parsePVSegment(m map[string]string) (PVSegment, error) {
+	var pvseg PVSegment
+	var err error
+	var count int64
+
+	pvseg.PVName = m["pv_name"]
+	pvseg.PVUUID = m["pv_uuid"]
+	pvseg.VGName = m["vg_name"]
+	pvseg.LVUUID = m["lv_uuid"]
+	pvseg.LVName, _ = trimHiddenName(m["lv_name"])
+
+	int64Map := map[string]*int64{
+		"pvseg_start": &pvseg.Start,
+		"pvseg_size":  &pvseg.Size,
+	}
+	for key, value := range int64Map {
+		count, err = strconv.ParseInt(m[key], 10, 64)
+		if err != nil {
+			err = fmt.Errorf("invalid format of %v=%v for segment of pv %v: %v", key, m[key], pvseg.PVName, err)
+			return pvseg, err
+		}
+		*value = count
+	}
+
+	return pvseg, err
+}
@@ -0,0 +1,138 @@
+package lvm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fixturesDir holds the recorded output of the lvm tools shared by the
+// tests of the lvm and collector packages.
+const fixturesDir = "../testdata"
+
+func TestListLVMReport(t *testing.T) {
+	defer SetRunner(runner)
+	SetRunner(FixtureRunner{Dir: fixturesDir})
+
+	report, err := ListLVMReport(context.Background())
+	if err != nil {
+		t.Fatalf("ListLVMReport: %v", err)
+	}
+
+	if len(report.VolumeGroups) != 1 {
+		t.Fatalf("got %d vgs, want 1", len(report.VolumeGroups))
+	}
+	vg := report.VolumeGroups[0]
+	if vg.Name != "vg0" || vg.ExtentSize.Value() != 4194304 || vg.FreeCount != 699 {
+		t.Errorf("got vg %s with extent size %v and %d free extents, want vg0 with 4194304 and 699",
+			vg.Name, vg.ExtentSize.Value(), vg.FreeCount)
+	}
+
+	// The orphan pv comes from a report of its own.
+	if len(report.PhysicalVolumes) != 3 {
+		t.Fatalf("got %d pvs, want 3", len(report.PhysicalVolumes))
+	}
+	if pv := report.PhysicalVolumes[2]; pv.Name != "/dev/sdd" || pv.VGName != "" {
+		t.Errorf("got pv %s in vg %q, want /dev/sdd in no vg", pv.Name, pv.VGName)
+	}
+
+	lvs := make(map[string]LogicalVolume)
+	for _, lv := range report.LogicalVolumes {
+		lvs[lv.Name] = lv
+	}
+	if len(lvs) != 15 {
+		t.Fatalf("got %d lvs, want 15", len(lvs))
+	}
+
+	pool := lvs["pool"]
+	if pool.SegType != LVThinPool || pool.Monitor != "monitored" || pool.UsedSizePercent != 50 {
+		t.Errorf("got pool with segtype %q, monitor %q and data percent %v, want %q, monitored and 50",
+			pool.SegType, pool.Monitor, pool.UsedSizePercent, LVThinPool)
+	}
+	if tdata := lvs["pool_tdata"]; !tdata.Hidden || tdata.Parent != "pool" {
+		t.Errorf("got pool_tdata hidden %v with parent %q, want hidden with parent pool", tdata.Hidden, tdata.Parent)
+	}
+
+	thin := lvs["thin1"]
+	if thin.Hidden || thin.PoolName != "pool" || len(thin.Tags) != 2 || thin.Tags[0] != "tenant=x" {
+		t.Errorf("got thin1 hidden %v in pool %q with tags %v, want visible in pool with tags [tenant=x backup]",
+			thin.Hidden, thin.PoolName, thin.Tags)
+	}
+	if thin.Monitor != "" {
+		t.Errorf("got thin1 monitor %q, want none", thin.Monitor)
+	}
+
+	snap := lvs["snap1"]
+	want := time.Date(2021, 7, 2, 8, 0, 0, 0, time.UTC)
+	if snap.Origin != "thin1" || !snap.CreationTime.Equal(want) {
+		t.Errorf("got snap1 of %q created at %v, want of thin1 created at %v", snap.Origin, snap.CreationTime, want)
+	}
+	if snap.Attr.State != "inactive" || snap.KernelMajor != -1 {
+		t.Errorf("got snap1 state %q with kernel major %d, want inactive with -1", snap.Attr.State, snap.KernelMajor)
+	}
+
+	// The origin of a cached lv is one of its hidden sub-volumes.
+	if cached := lvs["cached"]; cached.Origin != "cached_corig" || !cached.OriginHidden || cached.Attr.VolumeType != "cache" {
+		t.Errorf("got cached of %q (hidden %v) with volume type %q, want of hidden cached_corig with cache",
+			cached.Origin, cached.OriginHidden, cached.Attr.VolumeType)
+	}
+
+	if rmeta := lvs["r1_rmeta_1"]; rmeta.RawHealthStatus != "failed" || rmeta.HealthStatus != -1 {
+		t.Errorf("got r1_rmeta_1 health status %q (%d), want failed (-1)", rmeta.RawHealthStatus, rmeta.HealthStatus)
+	}
+
+	if len(report.Segments) != 16 || len(report.PVSegments) != 13 {
+		t.Errorf("got %d segments and %d pv segments, want 16 and 13", len(report.Segments), len(report.PVSegments))
+	}
+}
+
+func TestDecodeFullReportJSONSkipsInvalidRows(t *testing.T) {
+	raw := []byte(`{"report": [{
+		"vg": [{"vg_name": "vg0", "vg_size": "bad"}],
+		"lv": [
+			{"lv_name": "ok", "lv_uuid": "u1", "lv_size": "1024B"},
+			{"lv_name": "bad", "lv_uuid": "u2", "lv_size": "1KiB"}
+		]
+	}]}`)
+	before := ParseErrorCounts()
+
+	report, err := decodeFullReportJSON(raw)
+	if err != nil {
+		t.Fatalf("decodeFullReportJSON: %v", err)
+	}
+	if len(report.VolumeGroups) != 0 {
+		t.Errorf("got %d vgs, want the invalid one skipped", len(report.VolumeGroups))
+	}
+	// The vg name is still used for the lvs of the report.
+	if len(report.LogicalVolumes) != 1 || report.LogicalVolumes[0].VGName != "vg0" {
+		t.Errorf("got lvs %+v, want only ok in vg0", report.LogicalVolumes)
+	}
+	after := ParseErrorCounts()
+	if after["vg"]-before["vg"] != 1 || after["lv"]-before["lv"] != 1 {
+		t.Errorf("got %d vg and %d lv parse errors, want 1 and 1", after["vg"]-before["vg"], after["lv"]-before["lv"])
+	}
+
+	if _, err := decodeFullReportJSON([]byte("not json")); err == nil {
+		t.Error("got no error for invalid json")
+	}
+}
+
+func TestParseLogicalVolumeInvalidTime(t *testing.T) {
+	before := ParseErrorCounts()
+
+	// lvm.conf report/time_format can change the format of lv_time.
+	lv, err := parseLogicalVolume(map[string]string{
+		"lv_name": "lv0",
+		"lv_size": "1024B",
+		"lv_time": "1625133600",
+	})
+	if err != nil {
+		t.Fatalf("parseLogicalVolume: %v", err)
+	}
+	if !lv.CreationTime.IsZero() {
+		t.Errorf("got creation time %v, want zero", lv.CreationTime)
+	}
+	if got := ParseErrorCounts()["lv_time"] - before["lv_time"]; got != 1 {
+		t.Errorf("got %d lv_time parse errors, want 1", got)
+	}
+}
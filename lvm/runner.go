@@ -0,0 +1,175 @@
+package lvm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"k8s.io/klog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Runner runs a named lvm tool with the given arguments and returns
+// what it wrote to stdout and stderr along with its exit code.
+// The returned error is non-nil if the tool could not be run, exited
+// with a non-zero code or was stopped because ctx expired.
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, exitCode int, err error)
+}
+
+var (
+	// runner is used by every function of the package to run the lvm tools.
+	runner Runner = ExecRunner{}
+
+	// commandTimeout bounds the run time of every lvm tool, 0 means
+	// that only the deadline of the caller applies.
+	commandTimeout time.Duration
+)
+
+// SetRunner replaces the Runner used to run the lvm tools.
+func SetRunner(r Runner) {
+	runner = r
+}
+
+// SetCommandTimeout sets the maximum run time of a single lvm tool.
+func SetCommandTimeout(timeout time.Duration) {
+	commandTimeout = timeout
+}
+
+// ExecRunner is the default Runner which executes the lvm tools
+// found in $PATH. A tool still running when ctx expires is killed
+// along with its whole process group, and Run returns right away
+// without waiting for it to exit.
+type ExecRunner struct{}
+
+// Run implements Runner.
+func (ExecRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, int, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, -1, err
+	}
+
+	// The channel is buffered so that a tool exiting after Run returned
+	// is still reaped.
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-waitErr:
+	case <-ctx.Done():
+		// A tool in uninterruptible sleep, e.g. on the I/O of a dead iSCSI
+		// PV, only dies once the I/O completes, if ever. Its output is
+		// dropped since it is still being written.
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		return nil, nil, -1, ctx.Err()
+	}
+
+	exitCode := 0
+	if err != nil {
+		exitCode = -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	return stdout.Bytes(), stderr.Bytes(), exitCode, err
+}
+
+// FixtureRunner is a Runner which replays the recorded output of the lvm
+// tools from Dir instead of running them. The output of a command is looked
+// up by the tool name joined with its leading sub-command arguments, e.g.
+// `lvm fullreport --reportformat json` is replayed from:
+//
+//	<Dir>/lvm_fullreport.stdout    recorded stdout (required)
+//	<Dir>/lvm_fullreport.stderr    recorded stderr (optional)
+//	<Dir>/lvm_fullreport.exitcode  recorded exit code (optional, defaults to 0)
+type FixtureRunner struct {
+	Dir string
+}
+
+// Run implements Runner.
+func (f FixtureRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, -1, err
+	}
+	base := filepath.Join(f.Dir, strings.Replace(commandName(name, args), " ", "_", -1))
+
+	stdout, err := ioutil.ReadFile(base + ".stdout")
+	if err != nil {
+		return nil, nil, -1, err
+	}
+
+	stderr, err := ioutil.ReadFile(base + ".stderr")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, -1, err
+	}
+
+	exitCode := 0
+	raw, err := ioutil.ReadFile(base + ".exitcode")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, -1, err
+	}
+	if err == nil {
+		if exitCode, err = strconv.Atoi(strings.TrimSpace(string(raw))); err != nil {
+			return nil, nil, -1, fmt.Errorf("invalid exit code in %v.exitcode: %v", base, err)
+		}
+	}
+	if exitCode != 0 {
+		return stdout, stderr, exitCode, fmt.Errorf("exit status %d", exitCode)
+	}
+	return stdout, stderr, exitCode, nil
+}
+
+// commandName returns the tool name followed by all the arguments preceding
+// the first option, e.g. "lvm fullreport".
+func commandName(name string, args []string) string {
+	parts := []string{name}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// runCommand runs the named lvm tool with the configured Runner and
+// returns its stdout. The stderr of a failed command is returned as
+// part of the error, a command which did not complete in time returns
+// an error wrapping context.DeadlineExceeded.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+	}
+
+	stdout, stderr, exitCode, err := runner.Run(ctx, name, args...)
+	command := commandName(name, args)
+	recordExitCode(command, exitCode)
+	for _, warning := range ClassifyWarnings(stderr) {
+		klog.Warningf("lvm: %s: %s", command, warning.Message)
+		recordWarning(command, warning.Kind)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("%s %v timed out: %w", name, args, ctx.Err())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s %v: %v (exit code %d): %s",
+			name, args, err, exitCode, strings.TrimSpace(string(stderr)))
+	}
+	return stdout, nil
+}
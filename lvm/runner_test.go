@@ -0,0 +1,121 @@
+package lvm
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingRunner is a Runner whose tools never complete before ctx expires.
+type blockingRunner struct{}
+
+func (blockingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, int, error) {
+	<-ctx.Done()
+	return nil, nil, -1, ctx.Err()
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	defer SetRunner(runner)
+	defer SetCommandTimeout(commandTimeout)
+	SetRunner(blockingRunner{})
+	SetCommandTimeout(10 * time.Millisecond)
+
+	key := ExitCodeKey{Command: "lvm fullreport", ExitCode: -1}
+	before := ExitCodeCounts()[key]
+
+	_, err := runCommand(context.Background(), LVM, FullReport, "--reportformat", "json")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want one wrapping %v", err, context.DeadlineExceeded)
+	}
+	if got := ExitCodeCounts()[key] - before; got != 1 {
+		t.Errorf("got %d runs of lvm fullreport killed, want 1", got)
+	}
+}
+
+func TestRunCommandExitCode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lvm-fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"pvscan.stdout":   "",
+		"pvscan.stderr":   "  Device /dev/sdx not found.\n",
+		"pvscan.exitcode": "5\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer SetRunner(runner)
+	SetRunner(FixtureRunner{Dir: dir})
+
+	key := ExitCodeKey{Command: PVScan, ExitCode: 5}
+	before := ExitCodeCounts()[key]
+
+	err = ReloadLVMMetadataCache(context.Background())
+	if err == nil {
+		t.Fatal("got no error for exit code 5")
+	}
+	for _, want := range []string{"exit code 5", "Device /dev/sdx not found."} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got error %q, want it to contain %q", err, want)
+		}
+	}
+	if got := ExitCodeCounts()[key] - before; got != 1 {
+		t.Errorf("got %d runs of pvscan exiting with 5, want 1", got)
+	}
+}
+
+func TestFixtureRunnerMissingOutput(t *testing.T) {
+	r := FixtureRunner{Dir: fixturesDir}
+	if _, _, _, err := r.Run(context.Background(), "vgs", "--reportformat", "json"); err == nil {
+		t.Error("got no error for a command without recorded output")
+	}
+
+	stdout, _, exitCode, err := r.Run(context.Background(), LVMConfig, "--typeconfig", "full")
+	if err != nil || exitCode != 0 || len(stdout) == 0 {
+		t.Errorf("got stdout %q, exit code %d and error %v, want the recorded output", stdout, exitCode, err)
+	}
+}
+
+func TestExecRunner(t *testing.T) {
+	stdout, stderr, exitCode, err := ExecRunner{}.Run(context.Background(), "sh", "-c", "echo out; echo err >&2; exit 3")
+	if err == nil || exitCode != 3 {
+		t.Errorf("got exit code %d and error %v, want 3", exitCode, err)
+	}
+	if string(stdout) != "out\n" || string(stderr) != "err\n" {
+		t.Errorf("got stdout %q and stderr %q, want out and err", stdout, stderr)
+	}
+}
+
+func TestExecRunnerTimeout(t *testing.T) {
+	tests := map[string]string{
+		"ignoring SIGTERM": `trap "" TERM; sleep 60`,
+		// The sleep escapes the kill of the process group and keeps the
+		// output open, as a tool stuck in uninterruptible sleep would.
+		"surviving the kill": `setsid sleep 10 & wait`,
+	}
+	for name, script := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			begin := time.Now()
+			_, _, exitCode, err := ExecRunner{}.Run(ctx, "sh", "-c", script)
+			if !errors.Is(err, context.DeadlineExceeded) || exitCode != -1 {
+				t.Errorf("got exit code %d and error %v, want -1 and %v", exitCode, err, context.DeadlineExceeded)
+			}
+			if elapsed := time.Since(begin); elapsed > 2*time.Second {
+				t.Errorf("got Run returning after %v, want it to return once ctx expired", elapsed)
+			}
+		})
+	}
+}
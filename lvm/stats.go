@@ -0,0 +1,85 @@
+package lvm
+
+import (
+	"sync"
+)
+
+// WarningKey identifies the warnings of a kind printed by a command.
+type WarningKey struct {
+	Command string
+	Kind    string
+}
+
+// ExitCodeKey identifies the runs of a command which ended with an exit
+// code, -1 standing for a command which could not be run or was killed.
+type ExitCodeKey struct {
+	Command  string
+	ExitCode int
+}
+
+// The counters are kept by the package rather than by the collectors,
+// as a single command run can serve several collectors and scrapes.
+var (
+	statsMutex     sync.Mutex
+	warningCounts  = make(map[WarningKey]uint64)
+	exitCodeCounts = make(map[ExitCodeKey]uint64)
+
+	// parseErrorCounts counts the report rows which could not be
+	// parsed, by object type (vg, lv, pv, seg, pvseg).
+	parseErrorCounts = make(map[string]uint64)
+)
+
+func recordWarning(command, kind string) {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	warningCounts[WarningKey{Command: command, Kind: kind}]++
+}
+
+func recordExitCode(command string, exitCode int) {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	exitCodeCounts[ExitCodeKey{Command: command, ExitCode: exitCode}]++
+}
+
+func recordParseError(object string) {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	parseErrorCounts[object]++
+}
+
+// WarningCounts returns the number of warnings printed by the lvm tools
+// since the start of the process, by command and kind.
+func WarningCounts() map[WarningKey]uint64 {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	counts := make(map[WarningKey]uint64, len(warningCounts))
+	for key, count := range warningCounts {
+		counts[key] = count
+	}
+	return counts
+}
+
+// ExitCodeCounts returns the number of runs of the lvm tools since the
+// start of the process, by command and exit code.
+func ExitCodeCounts() map[ExitCodeKey]uint64 {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	counts := make(map[ExitCodeKey]uint64, len(exitCodeCounts))
+	for key, count := range exitCodeCounts {
+		counts[key] = count
+	}
+	return counts
+}
+
+// ParseErrorCounts returns the number of report rows which were skipped
+// since the start of the process because they could not be parsed, by
+// object type.
+func ParseErrorCounts() map[string]uint64 {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	counts := make(map[string]uint64, len(parseErrorCounts))
+	for object, count := range parseErrorCounts {
+		counts[object] = count
+	}
+	return counts
+}
@@ -0,0 +1,61 @@
+package lvm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kinds of the warnings printed by the lvm tools on stderr.
+const (
+	WarningMissingDevice         = "missing_device"
+	WarningDuplicatePV           = "duplicate_pv"
+	WarningMetadataInconsistency = "metadata_inconsistency"
+	WarningLockingFailure        = "locking_failure"
+	WarningOther                 = "other"
+)
+
+// warningPatterns matches each kind of warning against the messages lvm
+// prints for it. The kinds are tried in order, so that e.g. a duplicate
+// PV message mentioning a device is not taken for a missing device, nor
+// the device mismatch of a multipath PV for a metadata mismatch.
+var warningPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{WarningDuplicatePV, regexp.MustCompile(`(?i)duplicate|not using device|prefers device|device mismatch`)},
+	{WarningLockingFailure, regexp.MustCompile(`(?i)\block`)},
+	{WarningMissingDevice, regexp.MustCompile(`(?i)couldn't find device|missing|not found`)},
+	{WarningMetadataInconsistency, regexp.MustCompile(`(?i)inconsistent|mismatch|checksum|metadata`)},
+}
+
+// Warning is a single line printed by an lvm tool on stderr.
+type Warning struct {
+	// Kind is one of the Warning* constants.
+	Kind string
+
+	// Message is the line as printed by the tool.
+	Message string
+}
+
+// ClassifyWarnings splits the stderr of an lvm tool into lines and
+// classifies each of them by kind.
+func ClassifyWarnings(stderr []byte) []Warning {
+	var warnings []Warning
+	for _, line := range strings.Split(string(stderr), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		warnings = append(warnings, Warning{Kind: warningKind(line), Message: line})
+	}
+	return warnings
+}
+
+func warningKind(message string) string {
+	for _, wp := range warningPatterns {
+		if wp.pattern.MatchString(message) {
+			return wp.kind
+		}
+	}
+	return WarningOther
+}
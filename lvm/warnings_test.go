@@ -0,0 +1,84 @@
+package lvm
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyWarnings(t *testing.T) {
+	tests := []struct {
+		message string
+		kind    string
+	}{
+		{"WARNING: Device mismatch detected for vg0/lv0 which is accessing /dev/sdb instead of /dev/mapper/mpatha.", WarningDuplicatePV},
+		{"WARNING: Not using device /dev/sdc for PV 3R4lIY-bO5v-tzFk-0fAr-9lhE-jo3x-kvmRJD.", WarningDuplicatePV},
+		{"WARNING: PV 3R4lIY-bO5v-tzFk-0fAr-9lhE-jo3x-kvmRJD prefers device /dev/sdb because device is used by LV.", WarningDuplicatePV},
+		{"Found duplicate PV 3R4lIYbO5vtzFk0fAr9lhEjo3xkvmRJD: using /dev/sdc not /dev/sdb", WarningDuplicatePV},
+		{"WARNING: Couldn't find device with uuid 3R4lIY-bO5v-tzFk-0fAr-9lhE-jo3x-kvmRJD.", WarningMissingDevice},
+		{"WARNING: VG vg0 is missing PV 3R4lIY-bO5v-tzFk-0fAr-9lhE-jo3x-kvmRJD (last written to /dev/sdd).", WarningMissingDevice},
+		{"Volume group \"vg1\" not found", WarningMissingDevice},
+		{"WARNING: Locking disabled. Be careful! This could corrupt your metadata.", WarningLockingFailure},
+		{"Global lock failed: check that lvmlockd is running.", WarningLockingFailure},
+		{"WARNING: Inconsistent metadata found for VG vg0.", WarningMetadataInconsistency},
+		{"WARNING: Checksum error at offset 4608 on /dev/sdb.", WarningMetadataInconsistency},
+		{"WARNING: ignoring metadata seqno 5 on /dev/sdc for seqno 6 on /dev/sdb for VG vg0.", WarningMetadataInconsistency},
+		{"WARNING: Sum of all thin volume sizes (20.00 GiB) exceeds the size of thin pool vg0/pool (4.00 GiB).", WarningOther},
+	}
+	for _, test := range tests {
+		t.Run(test.kind, func(t *testing.T) {
+			warnings := ClassifyWarnings([]byte("  " + test.message + "\n\n"))
+			if len(warnings) != 1 {
+				t.Fatalf("got %d warnings for %q, want 1", len(warnings), test.message)
+			}
+			if warnings[0].Kind != test.kind || warnings[0].Message != test.message {
+				t.Errorf("got %+v, want %s for %q", warnings[0], test.kind, test.message)
+			}
+		})
+	}
+}
+
+func TestListLVMReportWithWarnings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lvm-fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	report, err := ioutil.ReadFile(filepath.Join(fixturesDir, "lvm_fullreport.stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"pvscan.stdout":         "",
+		"lvm_fullreport.stdout": string(report),
+		"lvm_fullreport.stderr": "  WARNING: Device mismatch detected for vg0/lv0 which is accessing /dev/sdb instead of /dev/mapper/mpatha.\n" +
+			"  WARNING: Couldn't find device with uuid 3R4lIY-bO5v-tzFk-0fAr-9lhE-jo3x-kvmRJD.\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer SetRunner(runner)
+	SetRunner(FixtureRunner{Dir: dir})
+
+	before := WarningCounts()
+	// The warnings on stderr do not get in the way of the json on stdout.
+	decoded, err := ListLVMReport(context.Background())
+	if err != nil {
+		t.Fatalf("ListLVMReport: %v", err)
+	}
+	if len(decoded.VolumeGroups) != 1 {
+		t.Errorf("got %d vgs, want 1", len(decoded.VolumeGroups))
+	}
+	after := WarningCounts()
+	for _, kind := range []string{WarningDuplicatePV, WarningMissingDevice} {
+		key := WarningKey{Command: "lvm fullreport", Kind: kind}
+		if got := after[key] - before[key]; got != 1 {
+			t.Errorf("got %d %s warnings, want 1", got, kind)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/Ab-hishek/LVM-exporter/collector"
+	"github.com/Ab-hishek/LVM-exporter/lvm"
+	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -11,6 +15,9 @@ import (
 	"gopkg.in/alecthomas/kingpin.v2"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -27,6 +34,34 @@ func main() {
 			"web.disable-exporter-metrics",
 			"Exclude metrics about the exporter itself (promhttp_*, process_*, go_*).",
 		).Default("true").Bool()
+		fixturesDir = kingpin.Flag(
+			"lvm.fixtures-dir",
+			"Replay the recorded output of the lvm tools from this directory instead of running them.",
+		).Hidden().String()
+		commandTimeout = kingpin.Flag(
+			"lvm.command-timeout",
+			"Maximum time a single lvm command may run before it is killed, 0 to disable.",
+		).Default("30s").Duration()
+		timeoutOffset = kingpin.Flag(
+			"web.timeout-offset",
+			"Offset to subtract from the timeout of the Prometheus scrape when waiting for the lvm commands.",
+		).Default("0.5s").Duration()
+		refreshInterval = kingpin.Flag(
+			"lvm.refresh-interval",
+			"Refresh the lvm snapshot in the background on this interval and serve the scrapes from it, 0 to run the lvm commands on every scrape.",
+		).Default("0s").Duration()
+		maxSnapshotAgeSet = false
+		maxSnapshotAge    = kingpin.Flag(
+			"lvm.max-snapshot-age",
+			"Fail the scrapes when the background lvm snapshot is older than this, 0 to disable. Defaults to 5m or 3 refresh intervals, whichever is longer.",
+		).Default("5m").Action(func(ctx *kingpin.ParseContext) error {
+			maxSnapshotAgeSet = true
+			return nil
+		}).Duration()
+		disableDefaultCollectors = kingpin.Flag(
+			"collector.disable-defaults",
+			"Set all collectors to disabled by default.",
+		).Default("false").Bool()
 	)
 
 	promlogConfig := &promlog.Config{}
@@ -36,8 +71,39 @@ func main() {
 	kingpin.Parse()
 
 	logger := promlog.New(promlogConfig)
+	if *disableDefaultCollectors {
+		collector.DisableDefaultCollectors()
+	}
+	if err := collector.ValidateTagLabels(); err != nil {
+		level.Error(logger).Log("msg", "Invalid --collector.tag-label", "err", err)
+		os.Exit(1)
+	}
 	level.Info(logger).Log("msg", "Starting lvm_exporter", "version", version.Info())
 	level.Info(logger).Log("msg", "Build context", "build_context", version.BuildContext())
+	level.Info(logger).Log("msg", "Enabled collectors", "collectors", strings.Join(collector.EnabledCollectors(), ","))
+
+	if *fixturesDir != "" {
+		level.Info(logger).Log("msg", "Replaying lvm command output", "dir", *fixturesDir)
+		lvm.SetRunner(lvm.FixtureRunner{Dir: *fixturesDir})
+	}
+	lvm.SetCommandTimeout(*commandTimeout)
+
+	var source collector.Source = collector.NewLiveSource()
+	if *refreshInterval > 0 {
+		// A snapshot gets as old as the interval before being refreshed,
+		// so a lower max age would fail the scrapes on every cycle.
+		if !maxSnapshotAgeSet && *maxSnapshotAge < 3**refreshInterval {
+			*maxSnapshotAge = 3 * *refreshInterval
+		}
+		if *maxSnapshotAge > 0 && *maxSnapshotAge <= *refreshInterval {
+			level.Error(logger).Log("msg", "--lvm.max-snapshot-age must be longer than --lvm.refresh-interval", "max_snapshot_age", *maxSnapshotAge, "refresh_interval", *refreshInterval)
+			os.Exit(1)
+		}
+		level.Info(logger).Log("msg", "Refreshing lvm snapshot in the background", "interval", *refreshInterval, "max_snapshot_age", *maxSnapshotAge)
+		cachedSource := collector.NewCachedSource(*refreshInterval, *maxSnapshotAge)
+		cachedSource.Start(context.Background())
+		source = cachedSource
+	}
 
 	registry := prometheus.NewRegistry()
 
@@ -48,23 +114,34 @@ func main() {
 		)
 	}
 	registry.MustRegister(version.NewCollector("lvm_exporter"))
+	registry.MustRegister(collector.NewStatsCollector())
 
-	//Create a new instance of the LvmVgCollector and
-	//register it with the prometheus client.
-	LvmVgCollector := collector.NewVgCollector()
-	registry.MustRegister(LvmVgCollector)
+	//The lvm collectors are created for every scrape so that they all
+	//share the single lvm report taken for that scrape. A scrape can be
+	//restricted to some of the enabled collectors with collect[] params.
+	http.HandleFunc(*metricsPath, func(w http.ResponseWriter, r *http.Request) {
+		filters := r.URL.Query()["collect[]"]
+		level.Debug(logger).Log("msg", "collect query:", "filters", strings.Join(filters, ","))
 
-	//Create a new instance of the LvmLvCollector and
-	//register it with the prometheus client.
-	LvmLvCollector := collector.NewLvCollector()
-	registry.MustRegister(LvmLvCollector)
+		ctx, cancel := scrapeContext(r, *timeoutOffset, logger)
+		defer cancel()
 
-	//Create a new instance of the LvmPvCollector and
-	//register it with the prometheus client.
-	LvmPvCollector := collector.NewPvCollector()
-	registry.MustRegister(LvmPvCollector)
+		scrape := collector.NewScrape(ctx, source)
+		lvmCollector, err := collector.NewLvmCollector(scrape, filters...)
+		if err != nil {
+			level.Warn(logger).Log("msg", "Couldn't create filtered metrics handler:", "err", err)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(fmt.Sprintf("Couldn't create filtered metrics handler: %s", err)))
+			return
+		}
+		lvmRegistry := prometheus.NewRegistry()
+		lvmRegistry.MustRegister(lvmCollector)
 
-	http.Handle(*metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		//The lvm collectors are gathered first so that the counters
+		//of the static registry include the commands of this scrape.
+		gatherers := prometheus.Gatherers{lvmRegistry, registry}
+		promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte(`<html>
 		<head><title>LVM Exporter</title></head>
@@ -82,3 +159,25 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// scrapeContext returns the context bounding how long a scrape waits for
+// the lvm commands. When Prometheus tells its scrape timeout in the
+// X-Prometheus-Scrape-Timeout-Seconds header, the scrape gives up offset
+// before that timeout so that the response can still be written.
+func scrapeContext(r *http.Request, offset time.Duration, logger log.Logger) (context.Context, context.CancelFunc) {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		return context.WithCancel(r.Context())
+	}
+
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil {
+		level.Warn(logger).Log("msg", "Invalid X-Prometheus-Scrape-Timeout-Seconds header", "value", header, "err", err)
+		return context.WithCancel(r.Context())
+	}
+	timeout := time.Duration(seconds*float64(time.Second)) - offset
+	if timeout <= 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}